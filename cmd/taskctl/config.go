@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vedologic/task-manager/config"
+)
+
+// configCommand runs the config subcommands; they do not need a valid configuration
+func configCommand(args []string) error {
+	name, args, err := subcommand("config", args, "print", "check")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "print":
+		return configPrint(args)
+	default:
+		return configCheck(args)
+	}
+}
+
+func configPrint(args []string) error {
+	fs := newFlagSet("config print")
+	redact := fs.Bool("redacted", false, "replace secrets with a placeholder")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return config.Print(os.Stdout, *redact)
+}
+
+func configCheck(args []string) error {
+	fs := newFlagSet("config check")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if _, err := config.Load(); err != nil {
+		return err
+	}
+	fmt.Println("Configuration is valid")
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/config"
+	"github.com/vedologic/task-manager/internal/server"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/logger"
+)
+
+// version is reported in traces; set it at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+const usage = `Usage: taskctl <command> [arguments]
+
+Commands:
+  serve                                 Run the API server
+  migrate up                            Apply all pending migrations
+  migrate down [-n N | -all -yes]       Roll back the last N migrations (default 1), or all of them
+  migrate status                        Show the applied and latest available schema version
+  migrate force VERSION                 Record VERSION as applied and clear the dirty flag
+  migrate create NAME                   Create an empty up/down migration pair
+  user create -email E [-role R]        Create a verified user; the password is read from stdin
+  user disable -user EMAIL|ID           Disable a user and revoke their sessions
+  user reset-password -user EMAIL|ID    Set a new password read from stdin and revoke sessions
+  task export -user EMAIL|ID [-format csv|ndjson|ics] [-status S] [-o FILE]
+  task import -user EMAIL|ID -file FILE [-format csv|ndjson] [-columns MAP] [-dry-run]
+  config print [-redacted]              Print the effective configuration as YAML
+  config check                          Validate the configuration
+
+Run "taskctl <command> <subcommand> -h" for the flags of a subcommand.
+Configuration is read from the files in CONFIG_FILES, .env, the environment and Vault, like the API server.
+`
+
+// errUsage reports invalid arguments; usage has already been printed
+var errUsage = errors.New("invalid arguments")
+
+// cli holds the configuration and lazily opened database shared by all commands
+type cli struct {
+	cfg *config.Config
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		if len(os.Args) < 2 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	// config inspects the configuration, so it runs even when the configuration is invalid
+	if os.Args[1] == "config" {
+		exit(configCommand(os.Args[2:]))
+		return
+	}
+
+	// Load configuration from config files, .env, environment variables and the secret provider
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskctl: failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	log, err := logger.New(cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskctl: failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	// SIGINT or SIGTERM cancels the running command
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	c := &cli{cfg: cfg, log: log}
+	err = c.run(ctx, os.Args[1], os.Args[2:])
+	c.close()
+	stop()
+	log.Sync()
+
+	exit(err)
+}
+
+// exit sets the exit status for the error of a command
+func exit(err error) {
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case errors.Is(err, flag.ErrHelp):
+		return
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "taskctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a top-level command
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "serve":
+		return server.Run(ctx, c.cfg, c.log, version)
+	case "migrate":
+		return c.migrate(ctx, args)
+	case "user":
+		return c.user(ctx, args)
+	case "task":
+		return c.task(ctx, args)
+	default:
+		fmt.Fprintf(os.Stderr, "taskctl: unknown command %q\n\n%s", command, usage)
+		return errUsage
+	}
+}
+
+// openDB connects to the database on first use
+func (c *cli) openDB() (*sqlx.DB, error) {
+	if c.db != nil {
+		return c.db, nil
+	}
+
+	db, err := database.NewPostgresDB(c.cfg.Database.Connection())
+	if err != nil {
+		return nil, err
+	}
+	c.db = db
+	return db, nil
+}
+
+// openRouter wraps the database for the repositories that route reads; commands have no replicas,
+// so every query uses the primary
+func (c *cli) openRouter() (*database.Router, error) {
+	db, err := c.openDB()
+	if err != nil {
+		return nil, err
+	}
+	return database.NewRouter(db, nil, 0, nil, c.log.Logger), nil
+}
+
+// close releases the database connection, if one was opened
+func (c *cli) close() {
+	database.Close(c.db)
+}
+
+// subcommand splits args into the subcommand name and its arguments
+func subcommand(command string, args []string, names ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, name := range names {
+			if args[0] == name {
+				return name, args[1:], nil
+			}
+		}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "taskctl: %s requires a subcommand\n\n%s", command, usage)
+	} else {
+		fmt.Fprintf(os.Stderr, "taskctl: unknown %s subcommand %q\n\n%s", command, args[0], usage)
+	}
+	return "", nil, errUsage
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("taskctl "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parses args, turning flag errors into errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
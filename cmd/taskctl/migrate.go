@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/vedologic/task-manager/migrations"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// migrate runs the migrate subcommands
+func (c *cli) migrate(ctx context.Context, args []string) error {
+	name, args, err := subcommand("migrate", args, "up", "down", "status", "force", "create")
+	if err != nil {
+		return err
+	}
+
+	// create only writes files, so it works without a database
+	if name == "create" {
+		return c.migrateCreate(args)
+	}
+
+	manager := database.NewMigrationManager(c.cfg.Database.Connection().URL(), migrations.FS, c.log.Logger)
+
+	switch name {
+	case "up":
+		return c.migrateUp(ctx, manager, args)
+	case "down":
+		return c.migrateDown(manager, args)
+	case "status":
+		return c.migrateStatus(manager, args)
+	default:
+		return c.migrateForce(manager, args)
+	}
+}
+
+func (c *cli) migrateUp(ctx context.Context, manager *database.MigrationManager, args []string) error {
+	fs := newFlagSet("migrate up")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := c.openDB()
+	if err != nil {
+		return err
+	}
+
+	if err := manager.RunMigrationsIfNeeded(ctx, db, c.cfg.Database.MigrationLockTimeout); err != nil {
+		return err
+	}
+
+	return printMigrationStatus(manager)
+}
+
+func (c *cli) migrateDown(manager *database.MigrationManager, args []string) error {
+	fs := newFlagSet("migrate down")
+	steps := fs.Int("n", 1, "number of migrations to roll back")
+	all := fs.Bool("all", false, "roll back every migration, dropping all data")
+	yes := fs.Bool("yes", false, "confirm -all")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var err error
+	switch {
+	case *all && !*yes:
+		fmt.Fprintln(os.Stderr, "taskctl: migrate down -all drops every table; add -yes to confirm")
+		return errUsage
+	case *all:
+		err = manager.DownMigrations()
+	case *steps < 1:
+		fmt.Fprintln(os.Stderr, "taskctl: -n must be at least 1")
+		return errUsage
+	default:
+		err = manager.StepDown(*steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+
+	return printMigrationStatus(manager)
+}
+
+func (c *cli) migrateStatus(manager *database.MigrationManager, args []string) error {
+	fs := newFlagSet("migrate status")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return printMigrationStatus(manager)
+}
+
+func (c *cli) migrateForce(manager *database.MigrationManager, args []string) error {
+	fs := newFlagSet("migrate force")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "taskctl: migrate force requires a VERSION")
+		return errUsage
+	}
+
+	version, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || version < -1 {
+		fmt.Fprintf(os.Stderr, "taskctl: invalid version %q\n", fs.Arg(0))
+		return errUsage
+	}
+
+	if err := manager.ForceVersion(version); err != nil {
+		return fmt.Errorf("failed to force version: %w", err)
+	}
+
+	return printMigrationStatus(manager)
+}
+
+func (c *cli) migrateCreate(args []string) error {
+	fs := newFlagSet("migrate create")
+	dir := fs.String("dir", database.MigrationsDir, "migrations directory")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "taskctl: migrate create requires a NAME")
+		return errUsage
+	}
+
+	upPath, downPath, err := database.CreateMigration(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(upPath)
+	fmt.Println(downPath)
+	return nil
+}
+
+// printMigrationStatus prints the applied version next to the latest one shipped with the binary
+func printMigrationStatus(manager *database.MigrationManager) error {
+	latest, err := manager.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := manager.GetMigrationStatus()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Printf("version: none\nlatest:  %d\n", latest)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read migration status: %w", err)
+	}
+
+	fmt.Printf("version: %d\nlatest:  %d\ndirty:   %t\n", version, latest, dirty)
+	if version > latest {
+		fmt.Println("The database was migrated by a newer release; this binary will refuse to start against it.")
+	}
+	if dirty {
+		fmt.Println("The last migration failed part-way. Repair the schema, then run: taskctl migrate force VERSION")
+	}
+	return nil
+}
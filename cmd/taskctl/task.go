@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/internal/service"
+	"github.com/vedologic/task-manager/internal/taskio"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// task runs the task subcommands
+func (c *cli) task(ctx context.Context, args []string) error {
+	name, args, err := subcommand("task", args, "export", "import")
+	if err != nil {
+		return err
+	}
+
+	if name == "export" {
+		return c.taskExport(ctx, args)
+	}
+	return c.taskImport(ctx, args)
+}
+
+func (c *cli) taskExport(ctx context.Context, args []string) error {
+	fs := newFlagSet("task export")
+	ref := fs.String("user", "", "email address or ID of the owner (required)")
+	format := fs.String("format", taskio.FormatCSV, "csv, ndjson or ics")
+	status := fs.String("status", "", "only export tasks with this status")
+	sort := fs.String("sort", string(domain.DefaultTaskSort), "sort field, prefixed with - for descending")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	userID, err := c.resolveUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := c.taskService()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	w := bufio.NewWriter(out)
+
+	exporter, err := taskio.NewExporter(*format, w)
+	if err != nil {
+		return err
+	}
+	if err := exporter.Begin(); err != nil {
+		return err
+	}
+
+	exported := 0
+	err = tasks.Export(ctx, userID, *status, domain.TaskSort(*sort), exporter.DueDatedOnly(), func(task *domain.Task) error {
+		exported++
+		return exporter.Write(task)
+	})
+	if err != nil {
+		return err
+	}
+	if err := exporter.End(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d tasks\n", exported)
+	return nil
+}
+
+func (c *cli) taskImport(ctx context.Context, args []string) error {
+	fs := newFlagSet("task import")
+	ref := fs.String("user", "", "email address or ID of the owner (required)")
+	path := fs.String("file", "", "file to import (required)")
+	format := fs.String("format", "", "csv or ndjson (default from the file extension)")
+	columns := fs.String("columns", "", "column mapping as field:column pairs, e.g. title:Name,status:State")
+	dryRun := fs.Bool("dry-run", false, "validate only, without storing tasks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "taskctl: -file is required")
+		return errUsage
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*path), ".")
+		if *format == "jsonl" {
+			*format = taskio.FormatNDJSON
+		}
+	}
+	resolved, err := taskio.ResolveImportFormat(*format, "")
+	if err != nil {
+		return err
+	}
+
+	mapping, err := taskio.ParseColumnMapping(*columns)
+	if err != nil {
+		return err
+	}
+
+	userID, err := c.resolveUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	rows, err := taskio.ParseImport(file, resolved, mapping)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := c.taskService()
+	if err != nil {
+		return err
+	}
+
+	resp, err := tasks.Import(ctx, userID, dto.TaskImportRequest{
+		Rows:   rows,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(resp); err != nil {
+		return err
+	}
+
+	if resp.InvalidRows > 0 {
+		return fmt.Errorf("%d of %d rows are invalid; nothing was imported", resp.InvalidRows, resp.TotalRows)
+	}
+	return nil
+}
+
+// taskService builds the task service on top of the database
+func (c *cli) taskService() (service.TaskService, error) {
+	db, err := c.openRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewTaskService(repository.NewTaskRepository(db), database.NewTxManager(db.DB, nil)), nil
+}
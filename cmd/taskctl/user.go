@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/internal/service"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// systemActor is the audit log actor for operator commands; it is recorded without a user
+const systemActor = ""
+
+// user runs the user subcommands
+func (c *cli) user(ctx context.Context, args []string) error {
+	name, args, err := subcommand("user", args, "create", "disable", "reset-password")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "create":
+		return c.userCreate(ctx, args)
+	case "disable":
+		return c.userDisable(ctx, args)
+	default:
+		return c.userResetPassword(ctx, args)
+	}
+}
+
+func (c *cli) userCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("user create")
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", string(domain.UserRoleUser), "role: user or admin")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	req := dto.CreateUserRequest{
+		Email:    *email,
+		Password: password,
+		Role:     domain.UserRole(*role),
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return fmt.Errorf("invalid user: %w", err)
+	}
+
+	admin, err := c.adminService()
+	if err != nil {
+		return err
+	}
+
+	user, err := admin.CreateUser(ctx, systemActor, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s %s (id %s)\n", user.Role, user.Email, user.ID)
+	return nil
+}
+
+func (c *cli) userDisable(ctx context.Context, args []string) error {
+	fs := newFlagSet("user disable")
+	ref := fs.String("user", "", "email address or ID of the user (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	userID, err := c.resolveUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	admin, err := c.adminService()
+	if err != nil {
+		return err
+	}
+
+	user, err := admin.DisableUser(ctx, systemActor, userID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Disabled %s (id %s)\n", user.Email, user.ID)
+	return nil
+}
+
+func (c *cli) userResetPassword(ctx context.Context, args []string) error {
+	fs := newFlagSet("user reset-password")
+	ref := fs.String("user", "", "email address or ID of the user (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	userID, err := c.resolveUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	req := dto.AdminResetPasswordRequest{Password: password}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+
+	admin, err := c.adminService()
+	if err != nil {
+		return err
+	}
+
+	if err := admin.ResetUserPassword(ctx, systemActor, userID, req); err != nil {
+		return err
+	}
+
+	fmt.Printf("Password reset for user %s; existing sessions were revoked\n", userID)
+	return nil
+}
+
+// adminService builds the admin service on top of the database
+func (c *cli) adminService() (service.AdminService, error) {
+	db, err := c.openRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewAdminService(
+		repository.NewUserRepository(db),
+		repository.NewTaskRepository(db),
+		repository.NewAuditLogRepository(db),
+		database.NewTxManager(db.DB, nil),
+		c.log.Logger,
+	), nil
+}
+
+// resolveUser returns the ID of the user given by email address or ID
+func (c *cli) resolveUser(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		fmt.Fprintln(os.Stderr, "taskctl: -user is required")
+		return "", errUsage
+	}
+
+	db, err := c.openRouter()
+	if err != nil {
+		return "", err
+	}
+	users := repository.NewUserRepository(db)
+
+	var user *domain.User
+	if _, err := strconv.Atoi(ref); err == nil {
+		user, err = users.FindByID(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("user %s: %w", ref, err)
+		}
+	} else {
+		user, err = users.FindByEmail(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("user %s: %w", ref, err)
+		}
+	}
+
+	return strconv.Itoa(user.ID), nil
+}
+
+// readPassword reads a password from the first line of stdin, so it stays out of shell history
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return "", fmt.Errorf("password must not be empty")
+	}
+
+	return password, nil
+}
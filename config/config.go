@@ -1,23 +1,44 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/spf13/viper"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/ratelimit"
+	"github.com/vedologic/task-manager/pkg/secrets"
+	"github.com/vedologic/task-manager/pkg/tracing"
+	"go.uber.org/zap/zapcore"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Log      LogConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Auth        AuthConfig
+	Mail        MailConfig
+	OIDC        OIDCConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
+	Log         LogConfig
+	Tracing     TracingConfig
+	Metrics     MetricsConfig
+	Health      HealthConfig
+	Secrets     SecretsConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Host string
-	Env  string
+	Port      string
+	Host      string
+	Env       string
+	PublicURL string
+	// TrustedProxies lists proxy IPs/CIDRs whose X-Forwarded-For header is used for the client IP
+	TrustedProxies []string
+	// ShutdownDrainDelay is how long readiness fails before the server stops accepting connections
+	ShutdownDrainDelay time.Duration
 }
 
 type DatabaseConfig struct {
@@ -30,6 +51,43 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// MigrateOnStartup applies pending migrations when the server starts; when false the server only
+	// checks that the schema version matches and migrations are run with taskctl
+	MigrateOnStartup bool
+	// MigrationLockTimeout bounds the wait for another replica holding the migration lock
+	MigrationLockTimeout time.Duration
+
+	// ReplicaURLs lists read replica connection strings; reads stay on the primary when empty
+	ReplicaURLs []string
+	// ReplicaStickiness keeps a user's reads on the primary for this long after they write
+	ReplicaStickiness time.Duration
+	// ReplicaCheckInterval is how often replicas are pinged to decide whether they receive reads
+	ReplicaCheckInterval time.Duration
+
+	// Connect bounds the wait for the database to accept connections at startup
+	Connect database.WaitPolicy
+
+	// Retry bounds the retries of reads and transactions failing with transient errors
+	Retry database.RetryPolicy
+	// BreakerThreshold consecutive connection failures open the circuit breaker; 0 disables it
+	BreakerThreshold int
+	// BreakerCooldown is how long the open circuit breaker fails calls fast before probing again
+	BreakerCooldown time.Duration
+}
+
+// Connection returns the settings used to open the database pool
+func (c DatabaseConfig) Connection() database.Config {
+	return database.Config{
+		Host:            c.Host,
+		Port:            c.Port,
+		User:            c.User,
+		Password:        c.Password,
+		DBName:          c.DBName,
+		SSLMode:         c.SSLMode,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+	}
 }
 
 type JWTConfig struct {
@@ -37,83 +95,397 @@ type JWTConfig struct {
 	ExpiryHours int
 }
 
+type AuthConfig struct {
+	RequireVerifiedEmail  bool
+	VerificationTokenTTL  time.Duration
+	PasswordResetTokenTTL time.Duration
+
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayBase       time.Duration
+	LoginMaxDelay        time.Duration
+
+	TOTPIssuer            string
+	TOTPEncryptionKey     string
+	TwoFactorChallengeTTL time.Duration
+
+	EmailChangeTokenTTL  time.Duration
+	ReauthWindow         time.Duration
+	AccountDeletionGrace time.Duration
+	AccountPurgeInterval time.Duration
+}
+
+type MailConfig struct {
+	Driver   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// OIDCConfig configures single sign-on; it is enabled when IssuerURL is set
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	StateTTL     time.Duration
+}
+
+// Enabled reports whether an identity provider is configured
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// RateLimitConfig holds per-route-group request limits, each given as "<requests>/<duration>"
+type RateLimitConfig struct {
+	Enabled bool
+	// Store is "memory" (per replica) or "postgres" (shared across replicas)
+	Store   string
+	Auth    ratelimit.Limit
+	Account ratelimit.Limit
+	Tasks   ratelimit.Limit
+	Views   ratelimit.Limit
+	Admin   ratelimit.Limit
+}
+
+type IdempotencyConfig struct {
+	// KeyTTL is how long a response is replayed for retries with the same Idempotency-Key
+	KeyTTL time.Duration
+}
+
 type LogConfig struct {
 	Level string
 }
 
-// Load loads configuration from environment variables and .env file
-func Load() (*Config, error) {
-	viper.SetConfigName(".env")
-	viper.SetConfigType("env")
-	viper.AddConfigPath(".")
+// TracingConfig configures OpenTelemetry span export over OTLP
+type TracingConfig struct {
+	Enabled     bool
+	ServiceName string
+	Endpoint    string
+	// Protocol is "grpc" or "http/protobuf"
+	Protocol    string
+	Insecure    bool
+	SampleRatio float64
+}
 
-	// Set default values
-	setDefaults()
+// MetricsConfig configures the Prometheus /metrics endpoint
+type MetricsConfig struct {
+	Enabled bool
+	// Port serves /metrics on a separate admin listener, keeping it off the public port; empty uses the API port
+	Port string
+}
 
-	// Read .env file if it exists
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading config file: %w", err)
-		}
-		// .env file not found, will use environment variables
-	}
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency check of the liveness and readiness probes
+	CheckTimeout time.Duration
+}
+
+// SecretsConfig configures the external secret provider, which is enabled by a Vault address
+type SecretsConfig struct {
+	// Path is the secret holding the settings, by environment variable name
+	Path string
+	// RefreshInterval is how often the secret is read again to pick up rotated database credentials
+	RefreshInterval time.Duration
+	Vault           secrets.VaultConfig
+}
 
-	// Bind environment variables
-	viper.AutomaticEnv()
+// Enabled reports whether settings are read from a secret provider
+func (c SecretsConfig) Enabled() bool {
+	return c.Vault.Addr != ""
+}
+
+// Environments
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Load loads configuration from the config files in CONFIG_FILES, .env and environment variables,
+// and validates it. Every invalid setting is reported, not only the first.
+func Load() (*Config, error) {
+	v, err := readSettings()
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{v: v}
 
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: viper.GetString("SERVER_PORT"),
-			Host: viper.GetString("SERVER_HOST"),
-			Env:  viper.GetString("ENV"),
+			Port:               l.string("server.port"),
+			Host:               l.string("server.host"),
+			Env:                l.string("server.env"),
+			PublicURL:          l.string("server.public_url"),
+			TrustedProxies:     l.list("server.trusted_proxies", ","),
+			ShutdownDrainDelay: l.duration("server.shutdown_drain_delay"),
 		},
 		Database: DatabaseConfig{
-			Host:            viper.GetString("DB_HOST"),
-			Port:            viper.GetInt("DB_PORT"),
-			User:            viper.GetString("DB_USER"),
-			Password:        viper.GetString("DB_PASSWORD"),
-			DBName:          viper.GetString("DB_NAME"),
-			SSLMode:         viper.GetString("DB_SSLMODE"),
-			MaxOpenConns:    viper.GetInt("DB_MAX_OPEN_CONNS"),
-			MaxIdleConns:    viper.GetInt("DB_MAX_IDLE_CONNS"),
-			ConnMaxLifetime: parseDuration(viper.GetString("DB_CONN_MAX_LIFETIME")),
+			Host:            l.string("database.host"),
+			Port:            l.int("database.port"),
+			User:            l.string("database.user"),
+			Password:        l.string("database.password"),
+			DBName:          l.string("database.name"),
+			SSLMode:         l.string("database.sslmode"),
+			MaxOpenConns:    l.int("database.max_open_conns"),
+			MaxIdleConns:    l.int("database.max_idle_conns"),
+			ConnMaxLifetime: l.duration("database.conn_max_lifetime"),
+
+			MigrateOnStartup:     l.bool("database.migrate_on_startup"),
+			MigrationLockTimeout: l.duration("database.migration_lock_timeout"),
+
+			ReplicaURLs:          l.list("database.replica_urls", ","),
+			ReplicaStickiness:    l.duration("database.replica_stickiness"),
+			ReplicaCheckInterval: l.duration("database.replica_check_interval"),
+
+			Connect: database.WaitPolicy{
+				MaxWait:      l.duration("database.connect.max_wait"),
+				InitialDelay: l.duration("database.connect.initial_delay"),
+				MaxDelay:     l.duration("database.connect.max_delay"),
+			},
+
+			Retry: database.RetryPolicy{
+				MaxAttempts: l.int("database.retry.max_attempts"),
+				BaseDelay:   l.duration("database.retry.base_delay"),
+				MaxDelay:    l.duration("database.retry.max_delay"),
+			},
+			BreakerThreshold: l.int("database.breaker.threshold"),
+			BreakerCooldown:  l.duration("database.breaker.cooldown"),
 		},
 		JWT: JWTConfig{
-			Secret:      viper.GetString("JWT_SECRET"),
-			ExpiryHours: viper.GetInt("JWT_EXPIRY_HOURS"),
+			Secret:      l.string("jwt.secret"),
+			ExpiryHours: l.int("jwt.expiry_hours"),
+		},
+		Auth: AuthConfig{
+			RequireVerifiedEmail:  l.bool("auth.require_verified_email"),
+			VerificationTokenTTL:  l.duration("auth.verification_token_ttl"),
+			PasswordResetTokenTTL: l.duration("auth.password_reset_token_ttl"),
+			LoginMaxFailures:      l.int("auth.login.max_failures"),
+			LoginIPMaxFailures:    l.int("auth.login.ip_max_failures"),
+			LoginFailureWindow:    l.duration("auth.login.failure_window"),
+			LoginLockoutDuration:  l.duration("auth.login.lockout_duration"),
+			LoginDelayBase:        l.duration("auth.login.delay_base"),
+			LoginMaxDelay:         l.duration("auth.login.max_delay"),
+			TOTPIssuer:            l.string("auth.totp.issuer"),
+			TOTPEncryptionKey:     l.string("auth.totp.encryption_key"),
+			TwoFactorChallengeTTL: l.duration("auth.totp.challenge_ttl"),
+			EmailChangeTokenTTL:   l.duration("auth.email_change_token_ttl"),
+			ReauthWindow:          l.duration("auth.reauthentication_window"),
+			AccountDeletionGrace:  l.duration("auth.account.deletion_grace_period"),
+			AccountPurgeInterval:  l.duration("auth.account.purge_interval"),
+		},
+		Mail: MailConfig{
+			Driver:   l.string("mail.driver"),
+			Host:     l.string("mail.smtp.host"),
+			Port:     l.int("mail.smtp.port"),
+			Username: l.string("mail.smtp.username"),
+			Password: l.string("mail.smtp.password"),
+			From:     l.string("mail.from"),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:    l.string("oidc.issuer_url"),
+			ClientID:     l.string("oidc.client_id"),
+			ClientSecret: l.string("oidc.client_secret"),
+			RedirectURL:  l.string("oidc.redirect_url"),
+			Scopes:       l.list("oidc.scopes", " "),
+			StateTTL:     l.duration("oidc.state_ttl"),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: l.bool("rate_limit.enabled"),
+			Store:   l.string("rate_limit.store"),
+			Auth:    l.limit("rate_limit.auth"),
+			Account: l.limit("rate_limit.account"),
+			Tasks:   l.limit("rate_limit.tasks"),
+			Views:   l.limit("rate_limit.views"),
+			Admin:   l.limit("rate_limit.admin"),
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: l.duration("idempotency.key_ttl"),
 		},
 		Log: LogConfig{
-			Level: viper.GetString("LOG_LEVEL"),
+			Level: l.string("log.level"),
+		},
+		Tracing: TracingConfig{
+			Enabled:     l.bool("tracing.enabled"),
+			ServiceName: l.string("tracing.service_name"),
+			Endpoint:    l.string("tracing.endpoint"),
+			Protocol:    l.string("tracing.protocol"),
+			Insecure:    l.bool("tracing.insecure"),
+			SampleRatio: l.float("tracing.sample_ratio"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: l.bool("metrics.enabled"),
+			Port:    l.string("metrics.port"),
+		},
+		Health: HealthConfig{
+			CheckTimeout: l.duration("health.check_timeout"),
 		},
+		Secrets: loadSecrets(l),
+	}
+
+	errs := l.errs
+	for _, validate := range []func() error{
+		cfg.Server.validate,
+		cfg.Database.validate,
+		cfg.JWT.validate,
+		cfg.RateLimit.validate,
+		cfg.Log.validate,
+		cfg.Tracing.validate,
+		cfg.Secrets.validate,
+	} {
+		if err := validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.Server.Production() {
+		errs = append(errs, cfg.validateProductionSecrets()...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	if cfg.OIDC.RedirectURL == "" {
+		cfg.OIDC.RedirectURL = strings.TrimRight(cfg.Server.PublicURL, "/") + "/api/v1/auth/oidc/callback"
 	}
 
 	return cfg, nil
 }
 
-// setDefaults sets default values for configuration
-func setDefaults() {
-	viper.SetDefault("SERVER_PORT", "8080")
-	viper.SetDefault("SERVER_HOST", "localhost")
-	viper.SetDefault("ENV", "development")
+// validate checks the store driver
+func (c RateLimitConfig) validate() error {
+	if c.Store != ratelimit.StoreMemory && c.Store != ratelimit.StorePostgres {
+		return fmt.Errorf("invalid RATE_LIMIT_STORE %q: expected %s or %s", c.Store, ratelimit.StoreMemory, ratelimit.StorePostgres)
+	}
+	return nil
+}
+
+// validate checks the environment and port
+func (c ServerConfig) validate() error {
+	switch c.Env {
+	case EnvDevelopment, EnvTest, EnvProduction:
+	default:
+		return fmt.Errorf("invalid ENV %q: expected %s, %s or %s", c.Env, EnvDevelopment, EnvTest, EnvProduction)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid SERVER_PORT %q: expected a port number", c.Port)
+	}
+	return nil
+}
+
+// Production reports whether the server runs in production, where unsafe defaults are refused
+func (c ServerConfig) Production() bool {
+	return c.Env == EnvProduction
+}
+
+// validate checks the token lifetime
+func (c JWTConfig) validate() error {
+	if c.ExpiryHours < 1 {
+		return fmt.Errorf("invalid JWT_EXPIRY_HOURS: expected at least 1")
+	}
+	return nil
+}
+
+// UsesDefaultSecret reports whether the JWT secret is the built-in development default
+func (c JWTConfig) UsesDefaultSecret() bool {
+	return c.Secret == defaultJWTSecret
+}
+
+// UsesDefaultTOTPEncryptionKey reports whether TOTP secrets are encrypted with the built-in development key
+func (c AuthConfig) UsesDefaultTOTPEncryptionKey() bool {
+	return c.TOTPEncryptionKey == defaultTOTPEncryptionKey
+}
+
+// minProductionSecretLength is the shortest JWT secret accepted in production
+const minProductionSecretLength = 32
+
+// validateProductionSecrets refuses the development defaults and short secrets in production
+func (c *Config) validateProductionSecrets() []error {
+	var errs []error
+	if c.JWT.UsesDefaultSecret() || len(c.JWT.Secret) < minProductionSecretLength {
+		errs = append(errs, fmt.Errorf("invalid JWT_SECRET: production requires a secret of at least %d characters other than the default", minProductionSecretLength))
+	}
+	if c.Database.Password == "" || c.Database.Password == defaultDBPassword {
+		errs = append(errs, fmt.Errorf("invalid DB_PASSWORD: production requires a password other than the default"))
+	}
+	if c.Auth.UsesDefaultTOTPEncryptionKey() || len(c.Auth.TOTPEncryptionKey) < minProductionSecretLength {
+		errs = append(errs, fmt.Errorf("invalid AUTH_TOTP_ENCRYPTION_KEY: production requires a key of at least %d characters other than the default", minProductionSecretLength))
+	}
+	return errs
+}
+
+// validate checks the log level
+func (c LogConfig) validate() error {
+	if _, err := zapcore.ParseLevel(c.Level); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q: expected debug, info, warn or error", c.Level)
+	}
+	return nil
+}
 
-	viper.SetDefault("DB_HOST", "localhost")
-	viper.SetDefault("DB_PORT", 5432)
-	viper.SetDefault("DB_USER", "taskmanager")
-	viper.SetDefault("DB_PASSWORD", "taskmanager123")
-	viper.SetDefault("DB_NAME", "taskmanager_db")
-	viper.SetDefault("DB_SSLMODE", "disable")
-	viper.SetDefault("DB_MAX_OPEN_CONNS", 25)
-	viper.SetDefault("DB_MAX_IDLE_CONNS", 5)
-	viper.SetDefault("DB_CONN_MAX_LIFETIME", "5m")
+// validate checks the pool size and the connection, retry and circuit breaker settings, and the
+// replica settings when read replicas are configured
+func (c DatabaseConfig) validate() error {
+	if c.MaxOpenConns < 1 {
+		return fmt.Errorf("invalid DB_MAX_OPEN_CONNS: expected at least 1")
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("invalid DB_MAX_IDLE_CONNS: expected between 0 and DB_MAX_OPEN_CONNS (%d)", c.MaxOpenConns)
+	}
+	if c.ConnMaxLifetime < 0 {
+		return fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: expected a non-negative duration")
+	}
+	if c.Connect.MaxWait < 0 {
+		return fmt.Errorf("invalid DB_CONNECT_MAX_WAIT: expected a non-negative duration")
+	}
+	if c.Connect.MaxWait > 0 && (c.Connect.InitialDelay <= 0 || c.Connect.MaxDelay < c.Connect.InitialDelay) {
+		return fmt.Errorf("invalid DB_CONNECT_INITIAL_DELAY or DB_CONNECT_MAX_DELAY: expected 0 < initial delay <= max delay")
+	}
+	if c.Retry.MaxAttempts < 1 {
+		return fmt.Errorf("invalid DB_RETRY_MAX_ATTEMPTS: expected at least 1")
+	}
+	if c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < c.Retry.BaseDelay {
+		return fmt.Errorf("invalid DB_RETRY_BASE_DELAY or DB_RETRY_MAX_DELAY: expected 0 <= base delay <= max delay")
+	}
+	if c.BreakerThreshold < 0 {
+		return fmt.Errorf("invalid DB_BREAKER_THRESHOLD: expected a non-negative number")
+	}
+	if c.BreakerThreshold > 0 && c.BreakerCooldown <= 0 {
+		return fmt.Errorf("invalid DB_BREAKER_COOLDOWN: expected a positive duration")
+	}
 
-	viper.SetDefault("JWT_SECRET", "your-secret-key-change-in-production")
-	viper.SetDefault("JWT_EXPIRY_HOURS", 24)
+	if len(c.ReplicaURLs) == 0 {
+		return nil
+	}
+	if c.ReplicaCheckInterval <= 0 {
+		return fmt.Errorf("invalid DB_REPLICA_CHECK_INTERVAL: expected a positive duration")
+	}
+	if c.ReplicaStickiness < 0 {
+		return fmt.Errorf("invalid DB_REPLICA_STICKINESS: expected a non-negative duration")
+	}
+	return nil
+}
 
-	viper.SetDefault("LOG_LEVEL", "info")
+// validate checks the OTLP protocol and sample ratio
+func (c TracingConfig) validate() error {
+	if c.Protocol != tracing.ProtocolGRPC && c.Protocol != tracing.ProtocolHTTP {
+		return fmt.Errorf("invalid OTEL_EXPORTER_OTLP_PROTOCOL %q: expected %s or %s", c.Protocol, tracing.ProtocolGRPC, tracing.ProtocolHTTP)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("invalid TRACING_SAMPLE_RATIO %v: expected a value between 0 and 1", c.SampleRatio)
+	}
+	return nil
 }
 
-// parseDuration parses duration string, returns 0 if invalid
-func parseDuration(d string) time.Duration {
-	duration, _ := time.ParseDuration(d)
-	return duration
+// parseList splits a comma-separated value, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTempDir runs the test in an empty working directory, so no .env is picked up, and returns it
+func useTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv(ConfigFilesEnv, "")
+	return dir
+}
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	useTempDir(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.Port != "8080" {
+		t.Errorf("Server.Port = %q, want %q", cfg.Server.Port, "8080")
+	}
+	if cfg.Database.MaxOpenConns != 25 {
+		t.Errorf("Database.MaxOpenConns = %d, want 25", cfg.Database.MaxOpenConns)
+	}
+	if cfg.Database.ConnMaxLifetime != 5*time.Minute {
+		t.Errorf("Database.ConnMaxLifetime = %s, want 5m", cfg.Database.ConnMaxLifetime)
+	}
+	if !cfg.Database.MigrateOnStartup {
+		t.Error("Database.MigrateOnStartup = false, want true")
+	}
+}
+
+func TestLoadLayersSources(t *testing.T) {
+	dir := useTempDir(t)
+
+	base := writeFile(t, dir, "base.yaml", `
+server:
+  port: "9001"
+  host: base.example.com
+database:
+  max_open_conns: 40
+  max_idle_conns: 10
+  conn_max_lifetime: 10m
+`)
+	override := writeFile(t, dir, "override.toml", `
+[server]
+port = "9002"
+
+[database]
+conn_max_lifetime = "15m"
+`)
+	t.Setenv(ConfigFilesEnv, base+","+override)
+
+	writeFile(t, dir, ".env", "DB_MAX_OPEN_CONNS=50\nDB_MAX_IDLE_CONNS=7\n")
+	t.Setenv("DB_MAX_IDLE_CONNS", "9")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"first file over the default", cfg.Server.Host, "base.example.com"},
+		{"later file over an earlier one", cfg.Server.Port, "9002"},
+		{"later file duration", cfg.Database.ConnMaxLifetime, 15 * time.Minute},
+		{".env over the files", cfg.Database.MaxOpenConns, 50},
+		{"environment over .env", cfg.Database.MaxIdleConns, 9},
+		{"default when no source sets it", cfg.Database.Port, 5432},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv(ConfigFilesEnv, writeFile(t, dir, "config.yaml", `
+database:
+  max_open_con: 40
+`))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() error = nil, want the unknown setting rejected")
+	}
+	if want := `config.yaml: unknown setting "database.max_open_con"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("Load() error = %v, want it to contain %q", err, want)
+	}
+}
+
+func TestLoadReportsEveryInvalidValue(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv(ConfigFilesEnv, writeFile(t, dir, "config.yaml", `
+database:
+  max_idle_conns: [1, 2]
+`))
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("MIGRATE_ON_STARTUP", "sometimes")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "5 minutes")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() error = nil, want the invalid values reported")
+	}
+
+	for _, want := range []string{
+		`invalid DB_MAX_OPEN_CONNS (database.max_open_conns) "many": expected an integer`,
+		`invalid DB_MAX_IDLE_CONNS (database.max_idle_conns) "[1 2]": expected an integer`,
+		`invalid MIGRATE_ON_STARTUP (database.migrate_on_startup) "sometimes": expected true or false`,
+		`invalid DB_CONN_MAX_LIFETIME (database.conn_max_lifetime) "5 minutes": expected a duration`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v\nwant it to contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/vedologic/task-manager/pkg/ratelimit"
+)
+
+// loader reads typed settings, collecting an error for every value that does not parse instead of
+// falling back to a zero value. Values come from the environment as strings and from config files
+// as strings or native YAML/TOML types.
+type loader struct {
+	v    *viper.Viper
+	errs []error
+}
+
+// fail records an invalid value
+func (l *loader) fail(key string, value any, expected string) {
+	l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected %s", settingsByKey[key].name(), fmt.Sprint(value), expected))
+}
+
+func (l *loader) string(key string) string {
+	switch value := l.v.Get(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (l *loader) int(key string) int {
+	switch value := l.v.Get(key).(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			l.fail(key, value, "an integer")
+		}
+		return n
+	default:
+		l.fail(key, value, "an integer")
+		return 0
+	}
+}
+
+func (l *loader) bool(key string) bool {
+	switch value := l.v.Get(key).(type) {
+	case bool:
+		return value
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			l.fail(key, value, "true or false")
+		}
+		return b
+	default:
+		l.fail(key, value, "true or false")
+		return false
+	}
+}
+
+func (l *loader) float(key string) float64 {
+	switch value := l.v.Get(key).(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			l.fail(key, value, "a number")
+		}
+		return f
+	default:
+		l.fail(key, value, "a number")
+		return 0
+	}
+}
+
+func (l *loader) duration(key string) time.Duration {
+	value := l.string(key)
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		l.fail(key, value, "a duration such as 30s, 5m or 1h")
+	}
+	return d
+}
+
+// list reads a list given as a config file list or as a string split on sep; a space separator
+// splits on any whitespace
+func (l *loader) list(key, sep string) []string {
+	switch value := l.v.Get(key).(type) {
+	case nil:
+		return nil
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		return items
+	case string:
+		if sep == " " {
+			return strings.Fields(value)
+		}
+		return parseList(value)
+	default:
+		l.fail(key, value, "a list")
+		return nil
+	}
+}
+
+// limit reads a rate limit given as "<requests>/<duration>"
+func (l *loader) limit(key string) ratelimit.Limit {
+	value := l.string(key)
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", settingsByKey[key].name(), err))
+	}
+	return limit
+}
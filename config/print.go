@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces secret values in Print output
+const redacted = "[REDACTED]"
+
+// Print writes the effective settings after layering as YAML, in the layout of the config files.
+// Values are printed as given, before validation, so an invalid configuration can be inspected.
+// With redact, secrets that are set are replaced by a placeholder.
+func Print(w io.Writer, redact bool) error {
+	v, err := readSettings()
+	if err != nil {
+		return err
+	}
+
+	var prev []string
+	for _, s := range settings {
+		path := strings.Split(s.key, ".")
+
+		// Open the groups this setting does not share with the previous one
+		shared := 0
+		for shared < len(path)-1 && shared < len(prev)-1 && path[shared] == prev[shared] {
+			shared++
+		}
+		for depth := shared; depth < len(path)-1; depth++ {
+			if depth == 0 && prev != nil {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s%s:\n", strings.Repeat("  ", depth), path[depth])
+		}
+		prev = path
+
+		value := formatValue(v.Get(s.key))
+		if redact && s.secret && value != `""` {
+			value = strconv.Quote(redacted)
+		}
+		if _, err := fmt.Fprintf(w, "%s%s: %s\n", strings.Repeat("  ", len(path)-1), path[len(path)-1], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatValue renders a setting as a YAML scalar or flow sequence
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return `""`
+	case string:
+		return strconv.Quote(value)
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, formatValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
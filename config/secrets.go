@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/vedologic/task-manager/pkg/secrets"
+)
+
+// secretFileSuffix marks the environment variable naming a file that holds a secret, as mounted by
+// Docker and Kubernetes secrets
+const secretFileSuffix = "_FILE"
+
+// loadSecrets reads the secret provider settings
+func loadSecrets(l *loader) SecretsConfig {
+	return SecretsConfig{
+		Path:            l.string("secrets.path"),
+		RefreshInterval: l.duration("secrets.refresh_interval"),
+		Vault: secrets.VaultConfig{
+			Addr:      l.string("secrets.vault.addr"),
+			Token:     l.string("secrets.vault.token"),
+			Namespace: l.string("secrets.vault.namespace"),
+			Mount:     l.string("secrets.vault.mount"),
+			KVVersion: l.int("secrets.vault.kv_version"),
+		},
+	}
+}
+
+// validate checks the Vault settings when a secret provider is enabled
+func (c SecretsConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if u, err := url.Parse(c.Vault.Addr); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid VAULT_ADDR %q: expected a URL such as http://127.0.0.1:8200", c.Vault.Addr)
+	}
+	if c.Vault.KVVersion != 1 && c.Vault.KVVersion != 2 {
+		return fmt.Errorf("invalid VAULT_KV_VERSION %d: expected 1 or 2", c.Vault.KVVersion)
+	}
+	if c.Vault.Mount == "" || c.Path == "" {
+		return fmt.Errorf("invalid VAULT_KV_MOUNT or SECRETS_PATH: expected a non-empty path")
+	}
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("invalid SECRETS_REFRESH_INTERVAL: expected a non-negative duration")
+	}
+	return nil
+}
+
+// provider returns the configured secret provider, or nil when none is enabled
+func (c SecretsConfig) provider() secrets.Provider {
+	if !c.Enabled() {
+		return nil
+	}
+	return secrets.NewVaultProvider(c.Vault)
+}
+
+// readSecretFiles sets each secret whose <ENV>_FILE variable names a file to the contents of that
+// file, without the trailing newline. Setting both the variable and its _FILE variant is an error.
+func readSecretFiles(v *viper.Viper) error {
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+		for _, name := range s.env {
+			path := os.Getenv(name + secretFileSuffix)
+			if path == "" {
+				continue
+			}
+			if _, ok := os.LookupEnv(name); ok {
+				return fmt.Errorf("%s and %s%s are both set; set only one", name, name, secretFileSuffix)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s%s: %w", name, secretFileSuffix, err)
+			}
+			v.Set(s.key, strings.TrimRight(string(data), "\r\n"))
+			break
+		}
+	}
+	return nil
+}
+
+// readSecretProvider overrides the settings stored in the secret provider, if one is configured.
+// The secret names settings by their environment variable, e.g. DB_PASSWORD.
+func readSecretProvider(v *viper.Viper) error {
+	l := &loader{v: v}
+	cfg := loadSecrets(l)
+	if err := cfg.validate(); err != nil {
+		l.errs = append(l.errs, err)
+	}
+	if len(l.errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(l.errs...))
+	}
+
+	provider := cfg.provider()
+	if provider == nil {
+		return nil
+	}
+
+	values, err := provider.Secrets(context.Background(), cfg.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets: %w", err)
+	}
+
+	byEnv := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		for _, name := range s.env {
+			byEnv[name] = s
+		}
+	}
+	for name, value := range values {
+		s, ok := byEnv[name]
+		if !ok {
+			return fmt.Errorf("failed to read secrets: secret %s sets unknown setting %q", cfg.Path, name)
+		}
+		if strings.HasPrefix(s.key, "secrets.") {
+			return fmt.Errorf("failed to read secrets: secret %s sets %s, which configures the secret provider itself", cfg.Path, name)
+		}
+		v.Set(s.key, value)
+	}
+	return nil
+}
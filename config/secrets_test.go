@@ -0,0 +1,114 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadReadsSecretFiles(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, dir, "db_password", "from-file\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "from-file" {
+		t.Errorf("Database.Password = %q, want %q", cfg.Database.Password, "from-file")
+	}
+}
+
+func TestLoadSecretFileOverridesConfigFiles(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv(ConfigFilesEnv, writeFile(t, dir, "config.yaml", "database:\n  password: from-config\n"))
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, dir, "db_password", "from-file"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "from-file" {
+		t.Errorf("Database.Password = %q, want %q", cfg.Database.Password, "from-file")
+	}
+}
+
+func TestLoadRejectsSecretFileConflicts(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv("DB_PASSWORD", "from-env")
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, dir, "db_password", "from-file"))
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "DB_PASSWORD and DB_PASSWORD_FILE are both set") {
+		t.Errorf("Load() error = %v, want the conflict reported", err)
+	}
+}
+
+func TestLoadRejectsMissingSecretFile(t *testing.T) {
+	dir := useTempDir(t)
+	t.Setenv("JWT_SECRET_FILE", filepath.Join(dir, "missing"))
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "failed to read JWT_SECRET_FILE") {
+		t.Errorf("Load() error = %v, want the unreadable file reported", err)
+	}
+}
+
+// useTestVault points the configuration at a Vault serving the KV version 2 secret task-manager
+func useTestVault(t *testing.T, data string) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/task-manager" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":` + data + `}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+}
+
+func TestLoadReadsSecretProvider(t *testing.T) {
+	useTempDir(t)
+	useTestVault(t, `{"DB_PASSWORD":"from-vault","DB_MAX_OPEN_CONNS":"30"}`)
+	t.Setenv("DB_PASSWORD", "from-env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "from-vault" {
+		t.Errorf("Database.Password = %q, want the Vault value over the environment", cfg.Database.Password)
+	}
+	if cfg.Database.MaxOpenConns != 30 {
+		t.Errorf("Database.MaxOpenConns = %d, want 30", cfg.Database.MaxOpenConns)
+	}
+}
+
+func TestLoadRejectsInvalidProviderSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"unknown setting", `{"DB_PASWORD":"x"}`, `sets unknown setting "DB_PASWORD"`},
+		{"provider setting", `{"VAULT_TOKEN":"x"}`, "configures the secret provider itself"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempDir(t)
+			useTestVault(t, tt.data)
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
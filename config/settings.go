@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/vedologic/task-manager/pkg/tracing"
+)
+
+// setting is a single configuration value. Config files nest it under its dotted key, e.g.
+//
+//	database:
+//	  max_open_conns: 25
+//
+// and the first of its environment variables that is set overrides the files.
+type setting struct {
+	key    string
+	env    []string
+	def    any
+	secret bool
+}
+
+// defaultJWTSecret lets development start without configuration; production refuses it
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// defaultDBPassword matches the development database; production refuses it
+const defaultDBPassword = "taskmanager123"
+
+// defaultTOTPEncryptionKey encrypts TOTP secrets in development; production refuses it. The key is
+// independent of the JWT secret, so rotating that secret does not make enrolled secrets unreadable.
+const defaultTOTPEncryptionKey = "development-totp-encryption-key-change-in-production"
+
+// settings lists every setting, grouped like Config; settings sharing a key prefix must be adjacent
+// so config print can nest them
+var settings = []setting{
+	{key: "server.port", env: []string{"SERVER_PORT"}, def: "8080"},
+	{key: "server.host", env: []string{"SERVER_HOST"}, def: "localhost"},
+	{key: "server.env", env: []string{"ENV", "SERVER_ENV"}, def: EnvDevelopment},
+	{key: "server.public_url", env: []string{"PUBLIC_URL"}, def: "http://localhost:8080"},
+	{key: "server.trusted_proxies", env: []string{"SERVER_TRUSTED_PROXIES"}, def: ""},
+	{key: "server.shutdown_drain_delay", env: []string{"SERVER_SHUTDOWN_DRAIN_DELAY"}, def: "5s"},
+
+	{key: "database.host", env: []string{"DB_HOST"}, def: "localhost"},
+	{key: "database.port", env: []string{"DB_PORT"}, def: 5432},
+	{key: "database.user", env: []string{"DB_USER"}, def: "taskmanager"},
+	{key: "database.password", env: []string{"DB_PASSWORD"}, def: defaultDBPassword, secret: true},
+	{key: "database.name", env: []string{"DB_NAME"}, def: "taskmanager_db"},
+	{key: "database.sslmode", env: []string{"DB_SSLMODE"}, def: "disable"},
+	{key: "database.max_open_conns", env: []string{"DB_MAX_OPEN_CONNS"}, def: 25},
+	{key: "database.max_idle_conns", env: []string{"DB_MAX_IDLE_CONNS"}, def: 5},
+	{key: "database.conn_max_lifetime", env: []string{"DB_CONN_MAX_LIFETIME"}, def: "5m"},
+	{key: "database.migrate_on_startup", env: []string{"MIGRATE_ON_STARTUP"}, def: true},
+	{key: "database.migration_lock_timeout", env: []string{"MIGRATION_LOCK_TIMEOUT"}, def: "5m"},
+	// Replica URLs may embed passwords
+	{key: "database.replica_urls", env: []string{"DB_REPLICA_URLS"}, def: "", secret: true},
+	{key: "database.replica_stickiness", env: []string{"DB_REPLICA_STICKINESS"}, def: "5s"},
+	{key: "database.replica_check_interval", env: []string{"DB_REPLICA_CHECK_INTERVAL"}, def: "5s"},
+	{key: "database.connect.max_wait", env: []string{"DB_CONNECT_MAX_WAIT"}, def: "1m"},
+	{key: "database.connect.initial_delay", env: []string{"DB_CONNECT_INITIAL_DELAY"}, def: "500ms"},
+	{key: "database.connect.max_delay", env: []string{"DB_CONNECT_MAX_DELAY"}, def: "5s"},
+	{key: "database.retry.max_attempts", env: []string{"DB_RETRY_MAX_ATTEMPTS"}, def: 3},
+	{key: "database.retry.base_delay", env: []string{"DB_RETRY_BASE_DELAY"}, def: "50ms"},
+	{key: "database.retry.max_delay", env: []string{"DB_RETRY_MAX_DELAY"}, def: "1s"},
+	{key: "database.breaker.threshold", env: []string{"DB_BREAKER_THRESHOLD"}, def: 5},
+	{key: "database.breaker.cooldown", env: []string{"DB_BREAKER_COOLDOWN"}, def: "10s"},
+
+	{key: "jwt.secret", env: []string{"JWT_SECRET"}, def: defaultJWTSecret, secret: true},
+	{key: "jwt.expiry_hours", env: []string{"JWT_EXPIRY_HOURS"}, def: 24},
+
+	{key: "auth.require_verified_email", env: []string{"AUTH_REQUIRE_VERIFIED_EMAIL"}, def: false},
+	{key: "auth.verification_token_ttl", env: []string{"AUTH_VERIFICATION_TOKEN_TTL"}, def: "48h"},
+	{key: "auth.password_reset_token_ttl", env: []string{"AUTH_PASSWORD_RESET_TOKEN_TTL"}, def: "1h"},
+	{key: "auth.email_change_token_ttl", env: []string{"AUTH_EMAIL_CHANGE_TOKEN_TTL"}, def: "24h"},
+	{key: "auth.reauthentication_window", env: []string{"AUTH_REAUTHENTICATION_WINDOW"}, def: "10m"},
+	{key: "auth.login.max_failures", env: []string{"AUTH_LOGIN_MAX_FAILURES"}, def: 10},
+	{key: "auth.login.ip_max_failures", env: []string{"AUTH_LOGIN_IP_MAX_FAILURES"}, def: 50},
+	{key: "auth.login.failure_window", env: []string{"AUTH_LOGIN_FAILURE_WINDOW"}, def: "15m"},
+	{key: "auth.login.lockout_duration", env: []string{"AUTH_LOGIN_LOCKOUT_DURATION"}, def: "15m"},
+	{key: "auth.login.delay_base", env: []string{"AUTH_LOGIN_DELAY_BASE"}, def: "1s"},
+	{key: "auth.login.max_delay", env: []string{"AUTH_LOGIN_MAX_DELAY"}, def: "30s"},
+	{key: "auth.totp.issuer", env: []string{"AUTH_TOTP_ISSUER"}, def: "Task Manager"},
+	{key: "auth.totp.encryption_key", env: []string{"AUTH_TOTP_ENCRYPTION_KEY"}, def: defaultTOTPEncryptionKey, secret: true},
+	{key: "auth.totp.challenge_ttl", env: []string{"AUTH_2FA_CHALLENGE_TTL"}, def: "5m"},
+	{key: "auth.account.deletion_grace_period", env: []string{"ACCOUNT_DELETION_GRACE_PERIOD"}, def: "720h"},
+	{key: "auth.account.purge_interval", env: []string{"ACCOUNT_PURGE_INTERVAL"}, def: "1h"},
+
+	{key: "mail.driver", env: []string{"MAIL_DRIVER"}, def: "log"},
+	{key: "mail.from", env: []string{"MAIL_FROM"}, def: "Task Manager <no-reply@localhost>"},
+	{key: "mail.smtp.host", env: []string{"SMTP_HOST"}, def: "localhost"},
+	{key: "mail.smtp.port", env: []string{"SMTP_PORT"}, def: 1025},
+	{key: "mail.smtp.username", env: []string{"SMTP_USERNAME"}, def: ""},
+	{key: "mail.smtp.password", env: []string{"SMTP_PASSWORD"}, def: "", secret: true},
+
+	{key: "oidc.issuer_url", env: []string{"OIDC_ISSUER_URL"}, def: ""},
+	{key: "oidc.client_id", env: []string{"OIDC_CLIENT_ID"}, def: ""},
+	{key: "oidc.client_secret", env: []string{"OIDC_CLIENT_SECRET"}, def: "", secret: true},
+	{key: "oidc.redirect_url", env: []string{"OIDC_REDIRECT_URL"}, def: ""},
+	{key: "oidc.scopes", env: []string{"OIDC_SCOPES"}, def: "openid email profile"},
+	{key: "oidc.state_ttl", env: []string{"OIDC_STATE_TTL"}, def: "10m"},
+
+	{key: "rate_limit.enabled", env: []string{"RATE_LIMIT_ENABLED"}, def: true},
+	{key: "rate_limit.store", env: []string{"RATE_LIMIT_STORE"}, def: "memory"},
+	{key: "rate_limit.auth", env: []string{"RATE_LIMIT_AUTH"}, def: "20/1m"},
+	{key: "rate_limit.account", env: []string{"RATE_LIMIT_ACCOUNT"}, def: "60/1m"},
+	{key: "rate_limit.tasks", env: []string{"RATE_LIMIT_TASKS"}, def: "300/1m"},
+	{key: "rate_limit.views", env: []string{"RATE_LIMIT_VIEWS"}, def: "120/1m"},
+	{key: "rate_limit.admin", env: []string{"RATE_LIMIT_ADMIN"}, def: "120/1m"},
+
+	{key: "idempotency.key_ttl", env: []string{"IDEMPOTENCY_KEY_TTL"}, def: "24h"},
+
+	{key: "log.level", env: []string{"LOG_LEVEL"}, def: "info"},
+
+	{key: "tracing.enabled", env: []string{"TRACING_ENABLED"}, def: false},
+	{key: "tracing.service_name", env: []string{"OTEL_SERVICE_NAME"}, def: "task-manager"},
+	{key: "tracing.endpoint", env: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"}, def: "localhost:4317"},
+	{key: "tracing.protocol", env: []string{"OTEL_EXPORTER_OTLP_PROTOCOL"}, def: tracing.ProtocolGRPC},
+	{key: "tracing.insecure", env: []string{"OTEL_EXPORTER_OTLP_INSECURE"}, def: true},
+	{key: "tracing.sample_ratio", env: []string{"TRACING_SAMPLE_RATIO"}, def: 1.0},
+
+	{key: "metrics.enabled", env: []string{"METRICS_ENABLED"}, def: true},
+	{key: "metrics.port", env: []string{"METRICS_PORT"}, def: ""},
+
+	{key: "health.check_timeout", env: []string{"HEALTH_CHECK_TIMEOUT"}, def: "2s"},
+
+	{key: "secrets.path", env: []string{"SECRETS_PATH"}, def: "task-manager"},
+	{key: "secrets.refresh_interval", env: []string{"SECRETS_REFRESH_INTERVAL"}, def: "5m"},
+	{key: "secrets.vault.addr", env: []string{"VAULT_ADDR"}, def: ""},
+	{key: "secrets.vault.token", env: []string{"VAULT_TOKEN"}, def: "", secret: true},
+	{key: "secrets.vault.namespace", env: []string{"VAULT_NAMESPACE"}, def: ""},
+	{key: "secrets.vault.mount", env: []string{"VAULT_KV_MOUNT"}, def: "secret"},
+	{key: "secrets.vault.kv_version", env: []string{"VAULT_KV_VERSION"}, def: 2},
+}
+
+// settingsByKey indexes settings for error messages
+var settingsByKey = func() map[string]setting {
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	return byKey
+}()
+
+// name identifies a setting in error messages by its environment variable and file key
+func (s setting) name() string {
+	return fmt.Sprintf("%s (%s)", s.env[0], s.key)
+}
+
+// ConfigFilesEnv lists YAML or TOML config files, comma-separated; later files override earlier ones
+const ConfigFilesEnv = "CONFIG_FILES"
+
+// dotEnvFile is read from the working directory when present
+const dotEnvFile = ".env"
+
+// readSettings layers the settings, from lowest to highest precedence: defaults, the files in
+// CONFIG_FILES in order, .env, the environment including the *_FILE variables of secrets, and the
+// secret provider
+func readSettings() (*viper.Viper, error) {
+	v := viper.New()
+	for _, s := range settings {
+		v.SetDefault(s.key, s.def)
+		if err := v.BindEnv(append([]string{s.key}, s.env...)...); err != nil {
+			return nil, fmt.Errorf("failed to bind %s: %w", s.key, err)
+		}
+	}
+
+	for i, path := range parseList(os.Getenv(ConfigFilesEnv)) {
+		v.SetConfigFile(path)
+		read := v.MergeInConfig
+		if i == 0 {
+			read = v.ReadInConfig
+		}
+		if err := read(); err != nil {
+			return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+		if err := checkKeys(v, path); err != nil {
+			return nil, err
+		}
+	}
+
+	// .env uses the environment variable names and overrides the config files
+	dotEnv, err := readDotEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := v.MergeConfigMap(dotEnv); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", dotEnvFile, err)
+	}
+
+	if err := readSecretFiles(v); err != nil {
+		return nil, err
+	}
+	if err := readSecretProvider(v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// readDotEnv returns the settings set in .env, nested by key
+func readDotEnv() (map[string]any, error) {
+	env := viper.New()
+	env.SetConfigFile(dotEnvFile)
+	env.SetConfigType("env")
+	if err := env.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// .env file not found, will use environment variables
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	for _, s := range settings {
+		for _, name := range s.env {
+			if env.IsSet(name) {
+				setNested(values, s.key, env.GetString(name))
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+// setNested stores value in m under a dotted key, creating the intermediate maps
+func setNested(m map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// checkKeys rejects keys that name no setting, which are usually typos
+func checkKeys(v *viper.Viper, path string) error {
+	for _, key := range v.AllKeys() {
+		if _, ok := settingsByKey[key]; !ok {
+			return fmt.Errorf("error reading config file %s: unknown setting %q", filepath.Base(path), key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix marks personal access tokens so they can be told apart from JWTs
+const AccessTokenPrefix = "tmpat_"
+
+type TokenScope string
+
+const (
+	ScopeTasksRead  TokenScope = "tasks:read"
+	ScopeTasksWrite TokenScope = "tasks:write"
+	ScopeViewsRead  TokenScope = "views:read"
+	ScopeViewsWrite TokenScope = "views:write"
+)
+
+// IsValid checks if the token scope is valid
+func (s TokenScope) IsValid() bool {
+	switch s {
+	case ScopeTasksRead, ScopeTasksWrite, ScopeViewsRead, ScopeViewsWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// PersonalAccessToken is a long-lived, scoped credential for scripts and CI.
+// Only the SHA-256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID          int        `db:"id"`
+	UserID      int        `db:"user_id"`
+	Name        string     `db:"name"`
+	TokenPrefix string     `db:"token_prefix"`
+	TokenHash   string     `db:"token_hash"`
+	Scopes      string     `db:"scopes"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	RevokedAt   *time.Time `db:"revoked_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// ScopeList returns the space-separated scopes as a slice
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// HasScope reports whether the token grants the scope
+func (t *PersonalAccessToken) HasScope(scope TokenScope) bool {
+	for _, s := range t.ScopeList() {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the token is neither revoked nor expired at the given time
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
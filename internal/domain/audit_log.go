@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// AuditLog records an administrative action
+type AuditLog struct {
+	ID          int64     `db:"id"`
+	ActorUserID *int      `db:"actor_user_id"`
+	Action      string    `db:"action"`
+	TargetType  string    `db:"target_type"`
+	TargetID    string    `db:"target_id"`
+	Details     string    `db:"details"`
+	CreatedAt   time.Time `db:"created_at"`
+}
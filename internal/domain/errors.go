@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Error kinds. Every domain error wraps one of these, so callers can test the kind with errors.Is
+// and the HTTP layer can map it to a status code.
+var (
+	ErrInvalidInput    = errors.New("invalid input")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrUnprocessable   = errors.New("unprocessable")
+	ErrTooLarge        = errors.New("too large")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUpstream        = errors.New("upstream failure")
+	ErrUnavailable     = errors.New("unavailable")
+)
+
+// Error is an error meant for API clients. Code is stable and machine-readable; Message is safe to show.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Fields lists per-field problems for validation errors
+	Fields []FieldError
+	// RetryAfter tells the client when to retry, for ErrTooManyRequests and ErrUnavailable
+	RetryAfter time.Duration
+}
+
+// FieldError describes a problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewError creates a domain error of the given kind
+func NewError(kind error, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// NewValidationError creates an invalid input error with per-field details
+func NewValidationError(fields []FieldError) *Error {
+	return &Error{
+		Kind:    ErrInvalidInput,
+		Code:    "validation_failed",
+		Message: "The request is invalid",
+		Fields:  fields,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind, so errors.Is(err, ErrNotFound) matches every not-found error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Is matches errors with the same code, so copies made by WithMessage or WithRetryAfter still match
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a more specific message
+func (e *Error) WithMessage(message string) *Error {
+	clone := *e
+	clone.Message = message
+	return &clone
+}
+
+// WithRetryAfter returns a copy of the error telling the client when to retry
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	clone := *e
+	clone.RetryAfter = d
+	return &clone
+}
+
+// Not found errors
+var (
+	ErrUserNotFound        = NewError(ErrNotFound, "user_not_found", "User not found")
+	ErrTaskNotFound        = NewError(ErrNotFound, "task_not_found", "Task not found")
+	ErrSavedViewNotFound   = NewError(ErrNotFound, "saved_view_not_found", "Saved view not found")
+	ErrAccessTokenNotFound = NewError(ErrNotFound, "access_token_not_found", "Access token not found")
+)
+
+// Conflict errors
+var (
+	ErrEmailTaken             = NewError(ErrConflict, "email_taken", "A user with this email already exists")
+	ErrSavedViewNameTaken     = NewError(ErrConflict, "saved_view_name_taken", "A saved view with this name already exists")
+	ErrIdentityAlreadyLinked  = NewError(ErrConflict, "identity_already_linked", "This identity is already linked to another user")
+	ErrTwoFactorAlreadyActive = NewError(ErrConflict, "two_factor_already_enabled", "Two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled    = NewError(ErrConflict, "two_factor_not_enabled", "Two-factor authentication is not enabled")
+	ErrTwoFactorNotStarted    = NewError(ErrConflict, "two_factor_enrollment_not_started", "Two-factor enrollment has not been started")
+	ErrEmailChangeCancelled   = NewError(ErrConflict, "email_change_cancelled", "The email change was cancelled or replaced by a newer request")
+)
+
+// Authentication and authorization errors
+var (
+	ErrInvalidCredentials       = NewError(ErrUnauthorized, "invalid_credentials", "Invalid email or password")
+	ErrInvalidPassword          = NewError(ErrUnauthorized, "invalid_password", "Invalid password")
+	ErrReauthenticationRequired = NewError(ErrUnauthorized, "reauthentication_required", "Sign in again to confirm this change")
+	ErrInvalidTwoFactorCode     = NewError(ErrUnauthorized, "invalid_two_factor_code", "Invalid two-factor code")
+	ErrInvalidChallenge         = NewError(ErrUnauthorized, "invalid_challenge", "Invalid or expired challenge token")
+	ErrSingleSignOnFailed       = NewError(ErrUnauthorized, "sso_failed", "Single sign-on failed")
+	ErrAuthenticationRequired   = NewError(ErrUnauthorized, "authentication_required", "Authorization header is required")
+	ErrInvalidAuthHeader        = NewError(ErrUnauthorized, "invalid_authorization_header", "Invalid authorization header format")
+	ErrInvalidAccessToken       = NewError(ErrUnauthorized, "invalid_token", "Invalid or expired token")
+	ErrAccountDisabled          = NewError(ErrForbidden, "account_disabled", "Account is disabled")
+	ErrEmailNotVerified         = NewError(ErrForbidden, "email_not_verified", "Email address has not been verified")
+	ErrInsufficientPermissions  = NewError(ErrForbidden, "insufficient_permissions", "Insufficient permissions")
+	ErrInsufficientScope        = NewError(ErrForbidden, "insufficient_scope", "Token is missing a required scope")
+	ErrSessionRequired          = NewError(ErrForbidden, "session_required", "Personal access tokens cannot be used for this endpoint")
+	ErrSelfModification         = NewError(ErrForbidden, "self_modification_forbidden", "Administrators cannot change their own role or status")
+)
+
+// Request errors
+var (
+	ErrInvalidRequest      = NewError(ErrInvalidInput, "invalid_request", "The request is invalid")
+	ErrInvalidEmailToken   = NewError(ErrInvalidInput, "invalid_email_token", "Token is invalid, expired or already used")
+	ErrNoPassword          = NewError(ErrInvalidInput, "no_password", "Account has no password; use password reset to set one")
+	ErrIdempotencyKeyReuse = NewError(ErrUnprocessable, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	ErrIdempotencyPending  = NewError(ErrConflict, "idempotency_key_in_progress", "A request with this Idempotency-Key is still being processed")
+	ErrLoginThrottled      = NewError(ErrTooManyRequests, "login_throttled", "Too many failed login attempts, try again later")
+	ErrRateLimited         = NewError(ErrTooManyRequests, "rate_limited", "Rate limit exceeded, retry later")
+	ErrRouteNotFound       = NewError(ErrNotFound, "route_not_found", "Route not found")
+	ErrRequestTooLarge     = NewError(ErrTooLarge, "request_too_large", "The request body is too large")
+)
+
+// Upstream errors
+var (
+	ErrIdentityProviderUnavailable = NewError(ErrUpstream, "identity_provider_unavailable", "Identity provider is unavailable")
+	ErrDatabaseUnavailable         = NewError(ErrUnavailable, "database_unavailable", "The service is temporarily unavailable, retry later")
+	ErrServiceStarting             = NewError(ErrUnavailable, "service_starting", "The service is starting, retry later")
+)
+
+// InvalidInput creates an invalid input error with a specific message
+func InvalidInput(message string) *Error {
+	return ErrInvalidRequest.WithMessage(message)
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// IdempotencyKey records a mutating request made with an Idempotency-Key header and, once
+// it has finished, the response to replay when the client retries it
+type IdempotencyKey struct {
+	UserID int    `db:"user_id"`
+	Key    string `db:"key"`
+	// RequestHash fingerprints the method, path and body so a reused key with a different request is detected
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   *int      `db:"status_code"`
+	ContentType  string    `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// IsCompleted reports whether the original request has finished and its response is stored
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != nil
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+type LoginThrottleKeyType string
+
+const (
+	LoginThrottleKeyAccount LoginThrottleKeyType = "account"
+	LoginThrottleKeyIP      LoginThrottleKeyType = "ip"
+)
+
+// LoginThrottle tracks recent failed logins for an account (by email) or a client IP
+type LoginThrottle struct {
+	KeyType       LoginThrottleKeyType `db:"key_type"`
+	Key           string               `db:"key"`
+	Failures      int                  `db:"failures"`
+	LastFailureAt time.Time            `db:"last_failure_at"`
+	LockedUntil   *time.Time           `db:"locked_until"`
+}
+
+// IsLocked reports whether login attempts are blocked at the given time
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
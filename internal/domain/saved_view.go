@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// SavedView is a named set of task list settings stored for a user
+type SavedView struct {
+	ID        int       `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	Status    string    `db:"status" json:"status,omitempty"`
+	Sort      string    `db:"sort" json:"sort,omitempty"`
+	PageSize  int       `db:"page_size" json:"page_size,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type TaskStatus string
 
@@ -20,12 +23,72 @@ func (ts TaskStatus) IsValid() bool {
 	}
 }
 
+// TaskSort is a task list ordering; a leading "-" means descending
+type TaskSort string
+
+const DefaultTaskSort TaskSort = "-created_at"
+
+// taskSortFields lists the columns tasks can be sorted by
+var taskSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"due_date":   true,
+	"title":      true,
+	"status":     true,
+}
+
+// IsValid checks if the sort refers to a sortable field
+func (s TaskSort) IsValid() bool {
+	return taskSortFields[s.Field()]
+}
+
+// Field returns the sorted field without the direction prefix
+func (s TaskSort) Field() string {
+	return strings.TrimPrefix(string(s), "-")
+}
+
+// Descending reports whether the sort is in descending order
+func (s TaskSort) Descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
 type Task struct {
 	ID          int        `db:"id" json:"id"`
 	UserID      int        `db:"user_id" json:"user_id"`
 	Title       string     `db:"title" json:"title"`
 	Description string     `db:"description" json:"description"`
 	Status      TaskStatus `db:"status" json:"status"`
+	DueDate     *time.Time `db:"due_date" json:"due_date,omitempty"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at,omitempty"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
+
+// SetStatus changes the task status and keeps CompletedAt in sync with it
+func (t *Task) SetStatus(status TaskStatus, now time.Time) {
+	t.Status = status
+
+	if status != TaskStatusDone {
+		t.CompletedAt = nil
+		return
+	}
+
+	if t.CompletedAt == nil {
+		t.CompletedAt = &now
+	}
+}
+
+// TaskStatsPeriod holds the created and completed counts for one time bucket
+type TaskStatsPeriod struct {
+	PeriodStart time.Time `db:"period_start"`
+	Created     int64     `db:"created"`
+	Completed   int64     `db:"completed"`
+}
+
+// TaskStats holds aggregated task metrics for a user
+type TaskStats struct {
+	StatusCounts         map[TaskStatus]int64
+	OverdueCount         int64
+	AvgTimeToDoneSeconds *float64
+	Timeline             []TaskStatsPeriod
+}
@@ -2,10 +2,67 @@ package domain
 
 import "time"
 
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin"
+)
+
+// IsValid checks if the user role is valid
+func (r UserRole) IsValid() bool {
+	switch r {
+	case UserRoleUser, UserRoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
-	ID           int       `db:"id" json:"id"`
-	Email        string    `db:"email" json:"email"`
-	PasswordHash string    `db:"password_hash" json:"-"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID              int        `db:"id" json:"id"`
+	Email           string     `db:"email" json:"email"`
+	PasswordHash    string     `db:"password_hash" json:"-"`
+	Role            UserRole   `db:"role" json:"role"`
+	DisplayName     string     `db:"display_name" json:"display_name"`
+	Timezone        string     `db:"timezone" json:"timezone"`
+	Locale          string     `db:"locale" json:"locale"`
+	DisabledAt      *time.Time `db:"disabled_at" json:"disabled_at,omitempty"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at,omitempty"`
+	// PendingEmail is the requested new address, applied once confirmed from that address
+	PendingEmail        *string    `db:"pending_email" json:"-"`
+	DeletionScheduledAt *time.Time `db:"deletion_scheduled_at" json:"deletion_scheduled_at,omitempty"`
+	// SessionVersion is embedded in issued JWTs; bumping it revokes all existing sessions
+	SessionVersion int `db:"session_version" json:"-"`
+	// TOTPSecret is encrypted at rest; it is set during enrollment and only active once TOTPEnabledAt is set
+	TOTPSecret       *string    `db:"totp_secret" json:"-"`
+	TOTPEnabledAt    *time.Time `db:"totp_enabled_at" json:"totp_enabled_at,omitempty"`
+	TOTPLastUsedStep *int64     `db:"totp_last_used_step" json:"-"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// IsDisabled reports whether the account has been disabled by an administrator
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsEmailVerified reports whether the user has confirmed ownership of their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsTOTPEnabled reports whether the user has confirmed two-factor authentication
+func (u *User) IsTOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
+}
+
+// IsDeletionScheduled reports whether the user has asked for the account to be deleted
+func (u *User) IsDeletionScheduled() bool {
+	return u.DeletionScheduledAt != nil
+}
+
+// HasPassword reports whether the account can sign in with a password; accounts provisioned by single sign-on cannot
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
 }
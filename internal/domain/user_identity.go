@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// UserIdentity links a user to an account at an external OpenID Connect provider
+type UserIdentity struct {
+	ID          int        `db:"id"`
+	UserID      int        `db:"user_id"`
+	Issuer      string     `db:"issuer"`
+	Subject     string     `db:"subject"`
+	Email       string     `db:"email"`
+	CreatedAt   time.Time  `db:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at"`
+}
+
+// ExternalIdentity is the verified result of a login at an external identity provider
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+type UserTokenPurpose string
+
+const (
+	UserTokenPurposeEmailVerification UserTokenPurpose = "email_verification"
+	UserTokenPurposePasswordReset     UserTokenPurpose = "password_reset"
+	UserTokenPurposeEmailChange       UserTokenPurpose = "email_change"
+)
+
+// UserToken is a single-use, expiring token sent to a user by email.
+// Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID        int              `db:"id"`
+	UserID    int              `db:"user_id"`
+	Purpose   UserTokenPurpose `db:"purpose"`
+	TokenHash string           `db:"token_hash"`
+	ExpiresAt time.Time        `db:"expires_at"`
+	UsedAt    *time.Time       `db:"used_at"`
+	CreatedAt time.Time        `db:"created_at"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+type AccessTokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateAccessTokenResponse includes the plaintext token, which is only ever returned once
+type CreateAccessTokenResponse struct {
+	Token string `json:"token"`
+	AccessTokenResponse
+}
+
+type AccessTokenListResponse struct {
+	Tokens []AccessTokenResponse `json:"tokens"`
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/vedologic/task-manager/internal/domain"
+)
+
+type AdminUserResponse struct {
+	ID         string          `json:"id"`
+	Email      string          `json:"email"`
+	Role       domain.UserRole `json:"role"`
+	Disabled   bool            `json:"disabled"`
+	DisabledAt string          `json:"disabled_at,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+type AdminUserListResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	TotalCount int64               `json:"total_count"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// CreateUserRequest creates an account directly, without email verification
+type CreateUserRequest struct {
+	Email    string          `json:"email" binding:"required,email"`
+	Password string          `json:"password" binding:"required,min=8"`
+	Role     domain.UserRole `json:"role" binding:"required"`
+}
+
+type AdminResetPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role domain.UserRole `json:"role" binding:"required"`
+}
+
+type TransferTasksRequest struct {
+	ToUserID string   `json:"to_user_id" binding:"required"`
+	TaskIDs  []string `json:"task_ids"`
+}
+
+type TransferTasksResponse struct {
+	TransferredCount int64 `json:"transferred_count"`
+}
+
+type AuditLogResponse struct {
+	ID          string          `json:"id"`
+	ActorUserID string          `json:"actor_user_id,omitempty"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type"`
+	TargetID    string          `json:"target_id,omitempty"`
+	Details     json.RawMessage `json:"details" swaggertype:"object"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+type AuditLogListResponse struct {
+	Entries    []AuditLogResponse `json:"entries"`
+	TotalCount int64              `json:"total_count"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	TotalPages int                `json:"total_pages"`
+}
@@ -10,12 +10,60 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// AuthResponse omits the token when login requires a verified email and the address is not verified yet.
+// When two-factor authentication is enabled, Login returns a ChallengeToken instead, to be
+// exchanged for a token at /auth/2fa/verify.
 type AuthResponse struct {
-	Token string   `json:"token"`
-	User  UserInfo `json:"user"`
+	Token             string   `json:"token,omitempty"`
+	TwoFactorRequired bool     `json:"two_factor_required,omitempty"`
+	ChallengeToken    string   `json:"challenge_token,omitempty"`
+	User              UserInfo `json:"user"`
 }
 
 type UserInfo struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	EmailVerified    bool   `json:"email_verified"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	// Code is a current TOTP code or an unused recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists recovery codes; they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorLoginRequest completes a two-step login with either a TOTP code or a recovery code
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code"`
 }
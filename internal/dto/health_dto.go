@@ -0,0 +1,15 @@
+package dto
+
+// HealthResponse is returned by the liveness and readiness probes
+type HealthResponse struct {
+	Status string        `json:"status" example:"pass"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthCheck is the outcome of a single dependency check
+type HealthCheck struct {
+	Name       string  `json:"name" example:"database"`
+	Status     string  `json:"status" example:"pass"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms" example:"1.25"`
+}
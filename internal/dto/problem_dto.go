@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/vedologic/task-manager/internal/domain"
+
+// Problem is the RFC 7807 application/problem+json body returned for every error.
+// Code is stable and meant for programs; Detail is meant for people and may change.
+type Problem struct {
+	Type     string              `json:"type" example:"urn:task-manager:problem:task_not_found"`
+	Title    string              `json:"title" example:"Not Found"`
+	Status   int                 `json:"status" example:"404"`
+	Detail   string              `json:"detail,omitempty" example:"Task not found"`
+	Instance string              `json:"instance,omitempty" example:"/api/v1/tasks/42"`
+	Code     string              `json:"code" example:"task_not_found"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/vedologic/task-manager/internal/domain"
+
+type SavedViewRequest struct {
+	Name     string            `json:"name" binding:"required,min=1,max=100"`
+	Status   domain.TaskStatus `json:"status"`
+	Sort     domain.TaskSort   `json:"sort"`
+	PageSize int               `json:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type SavedViewListResponse struct {
+	Views []domain.SavedView `json:"views"`
+}
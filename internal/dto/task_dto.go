@@ -1,17 +1,23 @@
 package dto
 
-import "github.com/vedologic/task-manager/internal/domain"
+import (
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+)
 
 type CreateTaskRequest struct {
 	Title       string            `json:"title" binding:"required,min=1,max=255"`
 	Description string            `json:"description"`
 	Status      domain.TaskStatus `json:"status" binding:"required"`
+	DueDate     *time.Time        `json:"due_date"`
 }
 
 type UpdateTaskRequest struct {
 	Title       string            `json:"title" binding:"required,min=1,max=255"`
 	Description string            `json:"description"`
 	Status      domain.TaskStatus `json:"status" binding:"required"`
+	DueDate     *time.Time        `json:"due_date"`
 }
 
 type BulkCompleteRequest struct {
@@ -24,6 +30,8 @@ type TaskResponse struct {
 	Title       string            `json:"title"`
 	Description string            `json:"description"`
 	Status      domain.TaskStatus `json:"status"`
+	DueDate     string            `json:"due_date,omitempty"`
+	CompletedAt string            `json:"completed_at,omitempty"`
 	CreatedAt   string            `json:"created_at"`
 	UpdatedAt   string            `json:"updated_at"`
 }
@@ -41,3 +49,53 @@ type BulkCompleteResponse struct {
 	FailedCount  int      `json:"failed_count"`
 	FailedIDs    []string `json:"failed_ids,omitempty"`
 }
+
+// TaskImportRow is a single parsed row of an import file
+type TaskImportRow struct {
+	Line   int
+	Task   CreateTaskRequest
+	Errors []string
+}
+
+// TaskImportRequest carries the parsed rows of an import file
+type TaskImportRequest struct {
+	Rows   []TaskImportRow
+	DryRun bool
+}
+
+type TaskImportRowError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+type TaskImportResponse struct {
+	DryRun        bool                 `json:"dry_run"`
+	TotalRows     int                  `json:"total_rows"`
+	ValidRows     int                  `json:"valid_rows"`
+	InvalidRows   int                  `json:"invalid_rows"`
+	ImportedCount int64                `json:"imported_count"`
+	Errors        []TaskImportRowError `json:"errors,omitempty"`
+}
+
+type TaskStatusCounts struct {
+	Todo       int64 `json:"todo"`
+	InProgress int64 `json:"in_progress"`
+	Done       int64 `json:"done"`
+	Total      int64 `json:"total"`
+}
+
+type TaskStatsPeriod struct {
+	PeriodStart string `json:"period_start"`
+	Created     int64  `json:"created"`
+	Completed   int64  `json:"completed"`
+}
+
+type TaskStatsResponse struct {
+	From                 string            `json:"from"`
+	To                   string            `json:"to"`
+	Interval             string            `json:"interval"`
+	StatusCounts         TaskStatusCounts  `json:"status_counts"`
+	OverdueCount         int64             `json:"overdue_count"`
+	AvgTimeToDoneSeconds *float64          `json:"avg_time_to_done_seconds"`
+	Timeline             []TaskStatsPeriod `json:"timeline"`
+}
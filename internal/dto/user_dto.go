@@ -0,0 +1,51 @@
+package dto
+
+import "time"
+
+type ProfileResponse struct {
+	ID                  string `json:"id"`
+	Email               string `json:"email"`
+	PendingEmail        string `json:"pending_email,omitempty"`
+	EmailVerified       bool   `json:"email_verified"`
+	DisplayName         string `json:"display_name"`
+	Timezone            string `json:"timezone"`
+	Locale              string `json:"locale"`
+	Role                string `json:"role"`
+	TwoFactorEnabled    bool   `json:"two_factor_enabled"`
+	HasPassword         bool   `json:"has_password"`
+	DeletionScheduledAt string `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           string `json:"created_at"`
+}
+
+// UpdateProfileRequest is a partial update; omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	// Timezone is an IANA time zone name such as Europe/Berlin
+	Timezone *string `json:"timezone" binding:"omitempty,max=64"`
+	// Locale is a BCP 47 language tag such as en-GB
+	Locale *string `json:"locale" binding:"omitempty,max=35"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	// Password is required for accounts that have one
+	Password string `json:"password"`
+	// AuthenticatedAt is when the session signed in, filled in from the access token
+	AuthenticatedAt time.Time `json:"-"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+type DeleteAccountRequest struct {
+	// Password is required for accounts that have one
+	Password string `json:"password"`
+	// AuthenticatedAt is when the session signed in, filled in from the access token
+	AuthenticatedAt time.Time `json:"-"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt string `json:"deletion_scheduled_at"`
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/service"
+	"go.uber.org/zap"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/middleware"
+)
+
+type AccessTokenHandler struct {
+	tokenService service.AccessTokenService
+	log          *zap.Logger
+}
+
+// NewAccessTokenHandler creates a new personal access token handler
+func NewAccessTokenHandler(tokenService service.AccessTokenService, log *zap.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenService: tokenService,
+		log:          log,
+	}
+}
+
+// Create godoc
+// @Summary Create a personal access token
+// @Description Issue a scoped token for scripts and CI. The token value is only returned once. Scopes: tasks:read, tasks:write, views:read, views:write
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAccessTokenRequest true "Access token request"
+// @Success 201 {object} dto.CreateAccessTokenResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/tokens [post]
+func (h *AccessTokenHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid create access token request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	token, err := h.tokenService.Create(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to create access token", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	requestLog(c, h.log).Info("Access token created",
+		zap.String("user_id", userID.(string)),
+		zap.String("token_id", token.ID),
+		zap.Strings("scopes", token.Scopes),
+	)
+	c.JSON(201, token)
+}
+
+// List godoc
+// @Summary List personal access tokens
+// @Description Get the authenticated user's tokens that have not been revoked. Token values are never returned
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.AccessTokenListResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/tokens [get]
+func (h *AccessTokenHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	tokens, err := h.tokenService.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to list access tokens", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, tokens)
+}
+
+// Revoke godoc
+// @Summary Revoke a personal access token
+// @Description Revoke a token so it can no longer be used
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param id path string true "Access token ID"
+// @Success 204
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/tokens/{id} [delete]
+func (h *AccessTokenHandler) Revoke(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	tokenID, ok := resourceID(c, domain.ErrAccessTokenNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.tokenService.Revoke(c.Request.Context(), tokenID, userID.(string)); err != nil {
+		requestLog(c, h.log).Warn("Failed to revoke access token", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	requestLog(c, h.log).Info("Access token revoked", zap.String("user_id", userID.(string)), zap.String("token_id", tokenID))
+	c.Status(204)
+}
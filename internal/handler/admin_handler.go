@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/middleware"
+	"github.com/vedologic/task-manager/internal/service"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	adminService service.AdminService
+	log          *zap.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService service.AdminService, log *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		log:          log,
+	}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Get all users with pagination (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} dto.AdminUserListResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	users, err := h.adminService.ListUsers(c.Request.Context(), actorID.(string), page, limit)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to list users", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, users)
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Set the role of a user (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.UpdateUserRoleRequest true "Role request"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/role [patch]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	userID, ok := resourceID(c, domain.ErrUserNotFound)
+	if !ok {
+		return
+	}
+	var req dto.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid update role request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	user, err := h.adminService.UpdateUserRole(c.Request.Context(), actorID.(string), userID, req.Role)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to update user role", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, user)
+}
+
+// DisableUser godoc
+// @Summary Disable a user account
+// @Description Disable a user so they can no longer log in or use existing tokens (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/disable [patch]
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	userID, ok := resourceID(c, domain.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.DisableUser(c.Request.Context(), actorID.(string), userID)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to disable user", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, user)
+}
+
+// EnableUser godoc
+// @Summary Enable a user account
+// @Description Re-enable a disabled user account (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/enable [patch]
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	userID, ok := resourceID(c, domain.ErrUserNotFound)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.EnableUser(c.Request.Context(), actorID.(string), userID)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to enable user", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, user)
+}
+
+// ListUserTasks godoc
+// @Summary List a user's tasks
+// @Description Get the tasks of any user with pagination and filtering (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param status query string false "Filter by status"
+// @Success 200 {object} dto.TaskListResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/tasks [get]
+func (h *AdminHandler) ListUserTasks(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	userID, ok := resourceID(c, domain.ErrUserNotFound)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	status := c.Query("status")
+
+	tasks, err := h.adminService.ListUserTasks(c.Request.Context(), actorID.(string), userID, page, limit, status)
+	if err != nil {
+		requestLog(c, h.log).Warn("Failed to list user tasks", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, tasks)
+}
+
+// TransferTasks godoc
+// @Summary Transfer a user's tasks
+// @Description Move the given tasks (or all tasks when task_ids is empty) from a user to another user (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Source user ID"
+// @Param request body dto.TransferTasksRequest true "Transfer request"
+// @Success 200 {object} dto.TransferTasksResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/tasks/transfer [post]
+func (h *AdminHandler) TransferTasks(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	userID, ok := resourceID(c, domain.ErrUserNotFound)
+	if !ok {
+		return
+	}
+	var req dto.TransferTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid transfer tasks request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.adminService.TransferTasks(c.Request.Context(), actorID.(string), userID, req)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to transfer tasks", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// ListAuditLogs godoc
+// @Summary List audit log entries
+// @Description Get the admin audit log, newest first (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} dto.AuditLogListResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/admin/audit-logs [get]
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	entries, err := h.adminService.ListAuditLogs(c.Request.Context(), actorID.(string), page, limit)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to list audit logs", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, entries)
+}
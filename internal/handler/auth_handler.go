@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
 	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/middleware"
 	"github.com/vedologic/task-manager/internal/service"
 	"go.uber.org/zap"
 )
@@ -22,27 +26,28 @@ func NewAuthHandler(authService service.AuthService, log *zap.Logger) *AuthHandl
 
 // Register godoc
 // @Summary User registration
-// @Description Register a new user
+// @Description Register a new user and send a verification email. No token is returned when login requires a verified email
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body dto.RegisterRequest true "Registration request"
 // @Success 201 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
 // @Router /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid register request", zap.Error(err))
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		requestLog(c, h.log).Warn("Invalid register request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
 		return
 	}
 
 	resp, err := h.authService.Register(c.Request.Context(), req)
 	if err != nil {
-		h.log.Error("Registration failed", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Registration failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
@@ -51,30 +56,302 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Login user and get JWT token
+// @Description Login user and get JWT token. When two-factor authentication is enabled the response carries a challenge_token for /api/v1/auth/2fa/verify instead
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginRequest true "Login request"
 // @Success 200 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid login request", zap.Error(err))
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		requestLog(c, h.log).Warn("Invalid login request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.authService.Login(c.Request.Context(), req, c.ClientIP())
+	if err != nil {
+		h.logLoginFailure(c, "Login failed", err)
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm an email address with the single-use token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "Verification request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Router /api/v1/auth/verify [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid verify email request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	if err := h.authService.VerifyEmail(c.Request.Context(), req); err != nil {
+		requestLog(c, h.log).Warn("Email verification failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Email address verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link. The response is the same whether or not the account exists
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.EmailRequest true "Email request"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Router /api/v1/auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req dto.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid resend verification request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	h.authService.ResendVerification(c.Request.Context(), req)
+
+	c.JSON(202, gin.H{"message": "If the account exists and is unverified, a verification email has been sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the account exists
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.EmailRequest true "Email request"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Router /api/v1/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req dto.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid forgot password request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	h.authService.ForgotPassword(c.Request.Context(), req)
+
+	c.JSON(202, gin.H{"message": "If the account exists, a password reset email has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the single-use token from the password reset email. Sessions issued before the reset are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Router /api/v1/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid reset password request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req); err != nil {
+		requestLog(c, h.log).Warn("Password reset failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Password has been reset"})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from login and a TOTP code (or a recovery code) for a JWT token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorLoginRequest true "Two-factor login request"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Router /api/v1/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid two-factor login request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.authService.VerifyTwoFactorLogin(c.Request.Context(), req, c.ClientIP())
+	if err != nil {
+		h.logLoginFailure(c, "Two-factor login failed", err)
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// EnrollTOTP godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and an otpauth:// URI to add to an authenticator app. Two-factor authentication is enabled once a code is confirmed
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.TOTPEnrollResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	resp, err := h.authService.EnrollTOTP(c.Request.Context(), userID.(string))
+	if err != nil {
+		requestLog(c, h.log).Warn("Two-factor enrollment failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm two-factor enrollment
+// @Description Verify a code from the authenticator app to enable two-factor authentication. Returns recovery codes, shown only once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.TOTPCodeRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid confirm two-factor request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.authService.ConfirmTOTP(c.Request.Context(), userID.(string), req, c.ClientIP())
+	if err != nil {
+		h.logLoginFailure(c, "Two-factor confirmation failed", err)
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	requestLog(c, h.log).Info("Two-factor authentication enabled", zap.String("user_id", userID.(string)))
+	c.JSON(200, resp)
+}
+
+// DisableTOTP godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication. Requires the password and a TOTP code or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.DisableTOTPRequest true "Disable two-factor request"
+// @Success 204
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid disable two-factor request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	if err := h.authService.DisableTOTP(c.Request.Context(), userID.(string), req, c.ClientIP()); err != nil {
+		h.logLoginFailure(c, "Disabling two-factor authentication failed", err)
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
-	resp, err := h.authService.Login(c.Request.Context(), req)
+	requestLog(c, h.log).Info("Two-factor authentication disabled", zap.String("user_id", userID.(string)))
+	c.Status(204)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after verifying a current TOTP code. The new codes are shown only once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.TOTPCodeRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid regenerate recovery codes request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userID.(string), req, c.ClientIP())
 	if err != nil {
-		h.log.Error("Login failed", zap.Error(err))
-		c.JSON(401, gin.H{"error": err.Error()})
+		h.logLoginFailure(c, "Regenerating recovery codes failed", err)
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
 	c.JSON(200, resp)
 }
+
+// logLoginFailure logs a failed login step, noting when the client is being throttled
+func (h *AuthHandler) logLoginFailure(c *gin.Context, msg string, err error) {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) && errors.Is(err, domain.ErrLoginThrottled) {
+		requestLog(c, h.log).Warn("Login throttled", zap.String("client_ip", c.ClientIP()), zap.Duration("retry_after", domainErr.RetryAfter))
+		return
+	}
+
+	requestLog(c, h.log).Warn(msg, zap.Error(err))
+}
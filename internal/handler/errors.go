@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/middleware"
+)
+
+// registerValidationFieldNames makes validation errors report JSON field names instead of Go field names
+func registerValidationFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// bindError converts a request binding error into an invalid input error with per-field details
+func bindError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]domain.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, domain.FieldError{
+				Field:   fieldPath(fe),
+				Message: validationMessage(fe),
+			})
+		}
+		return domain.NewValidationError(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return domain.NewValidationError([]domain.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be a " + jsonTypeName(typeErr.Type),
+		}})
+	}
+
+	var syntaxErr *json.SyntaxError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return domain.ErrRequestTooLarge
+	case errors.Is(err, io.EOF):
+		return domain.InvalidInput("Request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return domain.InvalidInput("Request body is not valid JSON")
+	}
+
+	return domain.InvalidInput("Invalid request")
+}
+
+// resourceID returns the :id path parameter. IDs that cannot exist are answered with notFound,
+// so malformed IDs behave like unknown ones.
+func resourceID(c *gin.Context, notFound error) (string, bool) {
+	id := c.Param("id")
+	if n, err := strconv.Atoi(id); err != nil || n < 1 {
+		middleware.AbortWithProblem(c, notFound)
+		return "", false
+	}
+	return id, true
+}
+
+// fieldPath returns the JSON path of a failed field without the request struct name
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// validationMessage describes a failed validation rule
+func validationMessage(fe validator.FieldError) string {
+	isText := fe.Kind() == reflect.String
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", snakeCase(fe.Param()))
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min", "gte":
+		switch {
+		case isText:
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		case isList:
+			return fmt.Sprintf("must contain at least %s %s", fe.Param(), pluralItems(fe.Param()))
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		switch {
+		case isText:
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		case isList:
+			return fmt.Sprintf("must contain at most %s %s", fe.Param(), pluralItems(fe.Param()))
+		}
+		return "must be at most " + fe.Param()
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+
+// pluralItems returns "item" or "items" to follow count
+func pluralItems(count string) string {
+	if count == "1" {
+		return "item"
+	}
+	return "items"
+}
+
+// jsonTypeName names the JSON type that decodes into t
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
+
+// snakeCase converts a Go field name such as RecoveryCode to recovery_code
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/pkg/health"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	health *health.Health
+	log    *zap.Logger
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(health *health.Health, log *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		health: health,
+		log:    log,
+	}
+}
+
+// Livez godoc
+// @Summary Liveness probe
+// @Description Reports whether the process is alive. A failure means it should be restarted.
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthResponse
+// @Failure 503 {object} dto.HealthResponse
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.respond(c, "Liveness", h.health.Liveness(c.Request.Context()))
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Reports whether the instance can serve traffic: the database is reachable and the schema is clean.
+// @Description Reports "starting" while the instance waits for the database at startup.
+// @Description Fails as soon as graceful shutdown begins, so load balancers stop routing to the instance.
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthResponse
+// @Failure 503 {object} dto.HealthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	h.respond(c, "Readiness", h.health.Readiness(c.Request.Context()))
+}
+
+// respond writes the report with 200 when healthy and 503 otherwise
+func (h *HealthHandler) respond(c *gin.Context, probe string, report health.Report) {
+	resp := dto.HealthResponse{
+		Status: report.Status,
+		Checks: make([]dto.HealthCheck, 0, len(report.Checks)),
+	}
+	for _, r := range report.Checks {
+		resp.Checks = append(resp.Checks, dto.HealthCheck{
+			Name:       r.Name,
+			Status:     r.Status,
+			Error:      r.Error,
+			DurationMs: float64(r.Duration.Microseconds()) / 1000,
+		})
+	}
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+		for _, r := range report.Checks {
+			if r.Error != "" {
+				requestLog(c, h.log).Warn(probe+" check failed", zap.String("check", r.Name), zap.String("error", r.Error))
+			}
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, resp)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/middleware"
+	"github.com/vedologic/task-manager/internal/service"
+	"go.uber.org/zap"
+)
+
+// oidcStateCookie holds the encrypted login state between the redirect and the callback
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	oidcService  service.OIDCService
+	cookiePath   string
+	cookieMaxAge int
+	secureCookie bool
+	log          *zap.Logger
+}
+
+// NewOIDCHandler creates a new OpenID Connect login handler.
+// The state cookie is marked Secure when the callback URL uses HTTPS.
+func NewOIDCHandler(oidcService service.OIDCService, redirectURL string, stateTTLSeconds int, log *zap.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:  oidcService,
+		cookiePath:   "/api/v1/auth/oidc",
+		cookieMaxAge: stateTTLSeconds,
+		secureCookie: strings.HasPrefix(redirectURL, "https://"),
+		log:          log,
+	}
+}
+
+// Login godoc
+// @Summary Start single sign-on
+// @Description Redirect to the identity provider to log in with OpenID Connect (authorization code flow with PKCE)
+// @Tags auth
+// @Success 302
+// @Failure 502 {object} dto.Problem
+// @Router /api/v1/auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	start, err := h.oidcService.BeginLogin(c.Request.Context())
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to start OIDC login", zap.Error(err))
+		middleware.AbortWithProblem(c, domain.ErrIdentityProviderUnavailable)
+		return
+	}
+
+	// SameSite=Lax keeps the cookie on the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, start.State, h.cookieMaxAge, h.cookiePath, "", h.secureCookie, true)
+	c.Redirect(302, start.AuthURL)
+}
+
+// Callback godoc
+// @Summary Complete single sign-on
+// @Description Handle the identity provider redirect, sign in (provisioning or linking the account by verified email) and return a JWT token
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Router /api/v1/auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	sealedState, _ := c.Cookie(oidcStateCookie)
+
+	// The state is single-use whatever the outcome
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, h.cookiePath, "", h.secureCookie, true)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		requestLog(c, h.log).Warn("Identity provider returned an error",
+			zap.String("error", providerErr),
+			zap.String("description", c.Query("error_description")),
+		)
+		middleware.AbortWithProblem(c, domain.InvalidInput("Login was not completed at the identity provider"))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		middleware.AbortWithProblem(c, domain.InvalidInput("Authorization code is required"))
+		return
+	}
+
+	resp, err := h.oidcService.CompleteLogin(c.Request.Context(), code, c.Query("state"), sealedState)
+	if err != nil {
+		requestLog(c, h.log).Warn("OIDC login failed", zap.Error(err))
+		middleware.AbortWithProblem(c, ssoError(err))
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// ssoError reports failures without a client-facing reason, such as a failed code exchange, as a generic single sign-on failure
+func ssoError(err error) error {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+	return domain.ErrSingleSignOnFailed
+}
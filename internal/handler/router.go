@@ -2,25 +2,57 @@ package handler
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/metrics"
 	"github.com/vedologic/task-manager/internal/middleware"
+	"github.com/vedologic/task-manager/pkg/logger"
 	"go.uber.org/zap"
 )
 
+// startupRetryAfter is suggested to clients calling the API before it has started
+const startupRetryAfter = 5 * time.Second
+
+// maxRequestBytes limits the JSON bodies of idempotent routes, which the idempotency middleware buffers
+const maxRequestBytes = 1 << 20
+
 // SetupRoutes configures all API routes and middleware
 func SetupRoutes(
 	router *gin.Engine,
 	authHandler *AuthHandler,
 	taskHandler *TaskHandler,
+	viewHandler *SavedViewHandler,
+	adminHandler *AdminHandler,
+	tokenHandler *AccessTokenHandler,
+	userHandler *UserHandler,
+	oidcHandler *OIDCHandler,
+	healthHandler *HealthHandler,
 	jwtSecret string,
+	users middleware.UserLookup,
+	tokens middleware.TokenAuthenticator,
+	rateLimiter *middleware.RateLimiter,
+	idempotency gin.HandlerFunc,
+	serviceName string,
+	serveMetrics bool,
 	log *zap.Logger,
 ) {
-	// Apply global middleware
+	registerValidationFieldNames()
+
+	// Apply global middleware; tracing and the request ID come first so every log line carries them
+	router.Use(middleware.Tracing(serviceName))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Metrics())
 	router.Use(middleware.LoggerMiddleware(log))
 	router.Use(middleware.RecoveryMiddleware(log))
+	router.Use(middleware.ErrorHandler(log))
+
+	router.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithProblem(c, domain.ErrRouteNotFound)
+	})
 
 	// Swagger UI
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -28,34 +60,137 @@ func SetupRoutes(
 		c.Redirect(301, "/swagger/index.html")
 	})
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "healthy"})
-	})
+	// Health checks; /health is kept as an alias of /readyz for existing monitors
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/health", healthHandler.Readyz)
+
+	// Prometheus metrics, unless they are served on a separate admin port
+	if serveMetrics {
+		router.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+	}
+
+	authMiddleware := middleware.AuthMiddleware(jwtSecret, users, tokens)
 
 	// Public routes - Auth
 	authRoutes := router.Group("/api/v1/auth")
+	authRoutes.Use(rateLimiter.Group(middleware.RateLimitGroupAuth))
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/verify", authHandler.VerifyEmail)
+		authRoutes.POST("/resend-verification", authHandler.ResendVerification)
+		authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+		authRoutes.POST("/reset-password", authHandler.ResetPassword)
+		authRoutes.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+		authRoutes.POST("/confirm-email-change", userHandler.ConfirmEmailChange)
+	}
+
+	// Public routes - Single sign-on (only when an identity provider is configured)
+	if oidcHandler != nil {
+		oidcRoutes := router.Group("/api/v1/auth/oidc")
+		oidcRoutes.Use(rateLimiter.Group(middleware.RateLimitGroupAuth))
+		{
+			oidcRoutes.GET("/login", oidcHandler.Login)
+			oidcRoutes.GET("/callback", oidcHandler.Callback)
+		}
+	}
+
+	// Protected routes - Two-factor authentication management (interactive sessions only)
+	twoFactorRoutes := router.Group("/api/v1/auth/2fa")
+	twoFactorRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupAccount), middleware.RequireJWT())
+	{
+		twoFactorRoutes.POST("/enroll", authHandler.EnrollTOTP)
+		twoFactorRoutes.POST("/confirm", authHandler.ConfirmTOTP)
+		twoFactorRoutes.POST("/disable", authHandler.DisableTOTP)
+		twoFactorRoutes.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	}
+
+	// Protected routes - Profile and account management (interactive sessions only)
+	meRoutes := router.Group("/api/v1/me")
+	meRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupAccount), middleware.RequireJWT())
+	{
+		meRoutes.GET("", userHandler.GetProfile)
+		meRoutes.PATCH("", userHandler.UpdateProfile)
+		meRoutes.DELETE("", userHandler.DeleteAccount)
+		meRoutes.POST("/email", userHandler.ChangeEmail)
+		meRoutes.POST("/password", userHandler.ChangePassword)
 	}
 
 	// Protected routes - Tasks
 	taskRoutes := router.Group("/api/v1/tasks")
-	taskRoutes.Use(middleware.AuthMiddleware(jwtSecret))
+	taskRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupTasks))
+	{
+		tasksRead := middleware.RequireScope(domain.ScopeTasksRead)
+		tasksWrite := middleware.RequireScope(domain.ScopeTasksWrite)
+		bodyLimit := middleware.MaxBodySize(maxRequestBytes)
+
+		taskRoutes.POST("", tasksWrite, bodyLimit, idempotency, taskHandler.Create)
+		taskRoutes.GET("", tasksRead, taskHandler.List)
+		taskRoutes.GET("/export", tasksRead, taskHandler.Export)
+		taskRoutes.GET("/stats", tasksRead, taskHandler.Stats)
+		taskRoutes.POST("/import", tasksWrite, middleware.MaxBodySize(maxImportBytes), idempotency, taskHandler.Import)
+		taskRoutes.GET("/:id", tasksRead, taskHandler.GetByID)
+		taskRoutes.PUT("/:id", tasksWrite, bodyLimit, idempotency, taskHandler.Update)
+		taskRoutes.DELETE("/:id", tasksWrite, bodyLimit, idempotency, taskHandler.Delete)
+		taskRoutes.PATCH("/bulk-complete", tasksWrite, bodyLimit, idempotency, taskHandler.BulkComplete)
+	}
+
+	// Protected routes - Saved views
+	viewRoutes := router.Group("/api/v1/views")
+	viewRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupViews))
+	{
+		viewsRead := middleware.RequireScope(domain.ScopeViewsRead)
+		viewsWrite := middleware.RequireScope(domain.ScopeViewsWrite)
+
+		viewRoutes.POST("", viewsWrite, viewHandler.Create)
+		viewRoutes.GET("", viewsRead, viewHandler.List)
+		viewRoutes.GET("/:id", viewsRead, viewHandler.GetByID)
+		viewRoutes.PUT("/:id", viewsWrite, viewHandler.Update)
+		viewRoutes.DELETE("/:id", viewsWrite, viewHandler.Delete)
+	}
+
+	// Protected routes - Personal access tokens (interactive sessions only)
+	tokenRoutes := router.Group("/api/v1/tokens")
+	tokenRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupAccount), middleware.RequireJWT())
+	{
+		tokenRoutes.POST("", tokenHandler.Create)
+		tokenRoutes.GET("", tokenHandler.List)
+		tokenRoutes.DELETE("/:id", tokenHandler.Revoke)
+	}
+
+	// Admin routes
+	adminRoutes := router.Group("/api/v1/admin")
+	adminRoutes.Use(authMiddleware, rateLimiter.Group(middleware.RateLimitGroupAdmin), middleware.RequireJWT(), middleware.RequireRole(domain.UserRoleAdmin))
 	{
-		taskRoutes.POST("", taskHandler.Create)
-		taskRoutes.GET("", taskHandler.List)
-		taskRoutes.GET("/:id", taskHandler.GetByID)
-		taskRoutes.PUT("/:id", taskHandler.Update)
-		taskRoutes.DELETE("/:id", taskHandler.Delete)
-		taskRoutes.PATCH("/bulk-complete", taskHandler.BulkComplete)
+		adminRoutes.GET("/users", adminHandler.ListUsers)
+		adminRoutes.PATCH("/users/:id/role", adminHandler.UpdateUserRole)
+		adminRoutes.PATCH("/users/:id/disable", adminHandler.DisableUser)
+		adminRoutes.PATCH("/users/:id/enable", adminHandler.EnableUser)
+		adminRoutes.GET("/users/:id/tasks", adminHandler.ListUserTasks)
+		adminRoutes.POST("/users/:id/tasks/transfer", adminHandler.TransferTasks)
+		adminRoutes.GET("/audit-logs", adminHandler.ListAuditLogs)
 	}
 
 	log.Info("Routes configured successfully")
 	printRegisteredRoutes(router, log)
 }
 
+// SetupStartupRoutes configures the routes served while the API starts: the health checks, which
+// report the instance as alive but not ready, and a 503 for everything else
+func SetupStartupRoutes(router *gin.Engine, healthHandler *HealthHandler, log *zap.Logger) {
+	router.Use(middleware.RecoveryMiddleware(log))
+	router.Use(middleware.ErrorHandler(log))
+
+	router.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithProblem(c, domain.ErrServiceStarting.WithRetryAfter(startupRetryAfter))
+	})
+
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/health", healthHandler.Readyz)
+}
+
 // printRegisteredRoutes logs all registered routes
 func printRegisteredRoutes(router *gin.Engine, log *zap.Logger) {
 	log.Info("Registered Routes:")
@@ -74,3 +209,8 @@ func printRegisteredRoutes(router *gin.Engine, log *zap.Logger) {
 	fmt.Println(separator + "\n")
 	log.Info("All routes loaded and ready!")
 }
+
+// requestLog returns log annotated with the request and trace IDs of the request
+func requestLog(c *gin.Context, log *zap.Logger) *zap.Logger {
+	return logger.WithContext(c.Request.Context(), log)
+}
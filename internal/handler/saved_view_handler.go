@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/service"
+	"go.uber.org/zap"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/middleware"
+)
+
+type SavedViewHandler struct {
+	viewService service.SavedViewService
+	log         *zap.Logger
+}
+
+// NewSavedViewHandler creates a new saved view handler
+func NewSavedViewHandler(viewService service.SavedViewService, log *zap.Logger) *SavedViewHandler {
+	return &SavedViewHandler{
+		viewService: viewService,
+		log:         log,
+	}
+}
+
+// Create godoc
+// @Summary Create a saved view
+// @Description Save a named combination of task filter, sort and page size
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param request body dto.SavedViewRequest true "Saved view request"
+// @Success 201 {object} domain.SavedView
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/views [post]
+func (h *SavedViewHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid create saved view request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	view, err := h.viewService.Create(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to create saved view", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(201, view)
+}
+
+// List godoc
+// @Summary List saved views
+// @Description Get all saved views for the authenticated user
+// @Tags views
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SavedViewListResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/views [get]
+func (h *SavedViewHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	views, err := h.viewService.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to list saved views", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, views)
+}
+
+// GetByID godoc
+// @Summary Get a saved view by ID
+// @Description Get a specific saved view by its ID
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path string true "Saved view ID"
+// @Success 200 {object} domain.SavedView
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/views/{id} [get]
+func (h *SavedViewHandler) GetByID(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	viewID, ok := resourceID(c, domain.ErrSavedViewNotFound)
+	if !ok {
+		return
+	}
+
+	view, err := h.viewService.GetByID(c.Request.Context(), viewID, userID.(string))
+	if err != nil {
+		requestLog(c, h.log).Warn("Failed to get saved view", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, view)
+}
+
+// Update godoc
+// @Summary Update a saved view
+// @Description Replace the settings of an existing saved view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path string true "Saved view ID"
+// @Param request body dto.SavedViewRequest true "Saved view request"
+// @Success 200 {object} domain.SavedView
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/views/{id} [put]
+func (h *SavedViewHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	viewID, ok := resourceID(c, domain.ErrSavedViewNotFound)
+	if !ok {
+		return
+	}
+	var req dto.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid update saved view request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	view, err := h.viewService.Update(c.Request.Context(), viewID, userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to update saved view", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, view)
+}
+
+// Delete godoc
+// @Summary Delete a saved view
+// @Description Delete a specific saved view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path string true "Saved view ID"
+// @Success 204
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/views/{id} [delete]
+func (h *SavedViewHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	viewID, ok := resourceID(c, domain.ErrSavedViewNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.viewService.Delete(c.Request.Context(), viewID, userID.(string)); err != nil {
+		requestLog(c, h.log).Error("Failed to delete saved view", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.Status(204)
+}
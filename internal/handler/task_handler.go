@@ -1,23 +1,42 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
 	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/middleware"
 	"github.com/vedologic/task-manager/internal/service"
+	"github.com/vedologic/task-manager/internal/taskio"
 	"go.uber.org/zap"
 )
 
+const (
+	// exportFlushEvery is the number of rows written between flushes to the client
+	exportFlushEvery = 200
+	// exportWriteTimeout is the write deadline granted to each batch of exported rows
+	exportWriteTimeout = 30 * time.Second
+
+	// maxImportBytes limits the size of an uploaded import file, which Idempotency buffers for retries
+	maxImportBytes = 10 << 20
+)
+
 type TaskHandler struct {
 	taskService service.TaskService
+	viewService service.SavedViewService
 	log         *zap.Logger
 }
 
 // NewTaskHandler creates a new task handler
-func NewTaskHandler(taskService service.TaskService, log *zap.Logger) *TaskHandler {
+func NewTaskHandler(taskService service.TaskService, viewService service.SavedViewService, log *zap.Logger) *TaskHandler {
 	return &TaskHandler{
 		taskService: taskService,
+		viewService: viewService,
 		log:         log,
 	}
 }
@@ -29,24 +48,27 @@ func NewTaskHandler(taskService service.TaskService, log *zap.Logger) *TaskHandl
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateTaskRequest true "Create task request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe; the first response is replayed"
 // @Success 201 {object} domain.Task
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks [post]
 func (h *TaskHandler) Create(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	var req dto.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid create task request", zap.Error(err))
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		requestLog(c, h.log).Warn("Invalid create task request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
 		return
 	}
 
 	task, err := h.taskService.Create(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		h.log.Error("Failed to create task", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Failed to create task", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
@@ -61,19 +83,22 @@ func (h *TaskHandler) Create(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Task ID"
 // @Success 200 {object} domain.Task
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks/{id} [get]
 func (h *TaskHandler) GetByID(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	taskID := c.Param("id")
+	taskID, ok := resourceID(c, domain.ErrTaskNotFound)
+	if !ok {
+		return
+	}
 
 	task, err := h.taskService.GetByID(c.Request.Context(), taskID, userID.(string))
 	if err != nil {
-		h.log.Warn("Failed to get task", zap.Error(err))
-		c.JSON(404, gin.H{"error": "Task not found"})
+		requestLog(c, h.log).Warn("Failed to get task", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
@@ -82,34 +107,229 @@ func (h *TaskHandler) GetByID(c *gin.Context) {
 
 // List godoc
 // @Summary List user tasks
-// @Description Get all tasks for the authenticated user with pagination and filtering
+// @Description Get all tasks for the authenticated user with pagination, filtering and sorting. A saved view supplies defaults that explicit query parameters override.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
 // @Param status query string false "Filter by status"
+// @Param sort query string false "Sort field, prefix with - for descending (created_at, updated_at, due_date, title, status)" default(-created_at)
+// @Param view query string false "Saved view ID to apply"
 // @Success 200 {object} dto.TaskListResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks [get]
 func (h *TaskHandler) List(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
 
-	tasks, err := h.taskService.List(c.Request.Context(), userID.(string), page, limit, status)
+	status, sort, pageSize, ok := h.taskFilters(c, userID.(string))
+	if !ok {
+		return
+	}
+	if _, ok := c.GetQuery("limit"); !ok && pageSize > 0 {
+		limit = pageSize
+	}
+
+	tasks, err := h.taskService.List(c.Request.Context(), userID.(string), page, limit, status, sort)
 	if err != nil {
-		h.log.Error("Failed to list tasks", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Failed to list tasks", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
 	c.JSON(200, tasks)
 }
 
+// taskFilters reads the status and sort query parameters, taking those not given explicitly from the
+// saved view named by the view parameter, and returns the view's page size (0 when it has none). It
+// aborts the request and returns false when the view cannot be loaded.
+func (h *TaskHandler) taskFilters(c *gin.Context, userID string) (status string, sort domain.TaskSort, pageSize int, ok bool) {
+	status = c.Query("status")
+	sort = domain.TaskSort(c.Query("sort"))
+
+	viewID := c.Query("view")
+	if viewID == "" {
+		return status, sort, 0, true
+	}
+
+	view, err := h.viewService.GetByID(c.Request.Context(), viewID, userID)
+	if err != nil {
+		requestLog(c, h.log).Warn("Failed to get saved view", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return "", "", 0, false
+	}
+
+	if _, ok := c.GetQuery("status"); !ok {
+		status = view.Status
+	}
+	if _, ok := c.GetQuery("sort"); !ok {
+		sort = domain.TaskSort(view.Sort)
+	}
+	return status, sort, view.PageSize, true
+}
+
+// Export godoc
+// @Summary Export tasks
+// @Description Stream all tasks for the authenticated user as CSV, NDJSON or iCalendar. Accepts the same filters as List without pagination; the iCalendar format contains only tasks with a due date, as VTODO entries.
+// @Tags tasks
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce text/calendar
+// @Param format query string false "Export format (csv, ndjson or ics)" default(csv)
+// @Param status query string false "Filter by status"
+// @Param sort query string false "Sort field, prefix with - for descending (created_at, updated_at, due_date, title, status)" default(-created_at)
+// @Param view query string false "Saved view ID to apply"
+// @Success 200 {file} file
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/tasks/export [get]
+func (h *TaskHandler) Export(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	status, sort, _, ok := h.taskFilters(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	exporter, err := taskio.NewExporter(c.DefaultQuery("format", taskio.FormatCSV), c.Writer)
+	if err != nil {
+		middleware.AbortWithProblem(c, domain.InvalidInput(err.Error()))
+		return
+	}
+
+	// Headers are only committed once the first row arrives, so filter errors can still be reported as JSON
+	started := false
+	begin := func() error {
+		started = true
+		c.Header("Content-Type", exporter.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, exporter.Extension()))
+		c.Status(200)
+		return exporter.Begin()
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	written := 0
+
+	err = h.taskService.Export(c.Request.Context(), userID.(string), status, sort, exporter.DueDatedOnly(), func(task *domain.Task) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+
+		// Extend the server write timeout for each batch so long exports are not cut off
+		if written%exportFlushEvery == 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		}
+
+		if err := exporter.Write(task); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			if err := exporter.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			requestLog(c, h.log).Error("Failed to export tasks", zap.Error(err))
+			middleware.AbortWithProblem(c, err)
+			return
+		}
+		// The response is already streaming; all we can do is stop and log
+		requestLog(c, h.log).Error("Task export aborted", zap.Int("rows_written", written), zap.Error(err))
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			requestLog(c, h.log).Error("Failed to write export", zap.Error(err))
+			return
+		}
+	}
+
+	if err := exporter.End(); err != nil {
+		requestLog(c, h.log).Error("Failed to finish export", zap.Error(err))
+	}
+}
+
+// Stats godoc
+// @Summary Task statistics
+// @Description Get task counts by status, created vs completed per day or week, average time-to-done and overdue count for the authenticated user
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param from query string false "Start of range (RFC 3339 or YYYY-MM-DD, inclusive); defaults to 30 days before to"
+// @Param to query string false "End of range (RFC 3339, exclusive, or YYYY-MM-DD, inclusive); defaults to now"
+// @Param interval query string false "Timeline bucket size (day or week)" default(day)
+// @Success 200 {object} dto.TaskStatsResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/tasks/stats [get]
+func (h *TaskHandler) Stats(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	to := time.Now()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := parseStatsTime(raw, true)
+		if err != nil {
+			middleware.AbortWithProblem(c, domain.InvalidInput("Invalid to parameter"))
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := parseStatsTime(raw, false)
+		if err != nil {
+			middleware.AbortWithProblem(c, domain.InvalidInput("Invalid from parameter"))
+			return
+		}
+		from = parsed
+	}
+
+	interval := c.DefaultQuery("interval", service.StatsIntervalDay)
+
+	stats, err := h.taskService.Stats(c.Request.Context(), userID.(string), from, to, interval)
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to get task stats", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, stats)
+}
+
+// parseStatsTime parses an RFC 3339 timestamp or a YYYY-MM-DD date; a date used as the
+// end of a range covers the whole day
+func parseStatsTime(raw string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
 // Update godoc
 // @Summary Update a task
 // @Description Update an existing task
@@ -118,26 +338,32 @@ func (h *TaskHandler) List(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Task ID"
 // @Param request body dto.UpdateTaskRequest true "Update task request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe; the first response is replayed"
 // @Success 200 {object} domain.Task
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks/{id} [put]
 func (h *TaskHandler) Update(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	taskID := c.Param("id")
+	taskID, ok := resourceID(c, domain.ErrTaskNotFound)
+	if !ok {
+		return
+	}
 	var req dto.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid update task request", zap.Error(err))
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		requestLog(c, h.log).Warn("Invalid update task request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
 		return
 	}
 
 	task, err := h.taskService.Update(c.Request.Context(), taskID, userID.(string), req)
 	if err != nil {
-		h.log.Error("Failed to update task", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Failed to update task", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
@@ -151,19 +377,25 @@ func (h *TaskHandler) Update(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe; the first response is replayed"
 // @Success 204
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks/{id} [delete]
 func (h *TaskHandler) Delete(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	taskID := c.Param("id")
+	taskID, ok := resourceID(c, domain.ErrTaskNotFound)
+	if !ok {
+		return
+	}
 
 	err := h.taskService.Delete(c.Request.Context(), taskID, userID.(string))
 	if err != nil {
-		h.log.Error("Failed to delete task", zap.Error(err))
-		c.JSON(404, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Failed to delete task", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
@@ -172,31 +404,104 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 
 // BulkComplete godoc
 // @Summary Mark multiple tasks as completed
-// @Description Mark multiple tasks as completed concurrently
+// @Description Mark multiple tasks as completed in one transaction; tasks that cannot be completed are reported in failed_ids
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param request body dto.BulkCompleteRequest true "Bulk complete request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe; the first response is replayed"
 // @Success 200 {object} dto.BulkCompleteResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
 // @Security BearerAuth
 // @Router /api/v1/tasks/bulk-complete [patch]
 func (h *TaskHandler) BulkComplete(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	var req dto.BulkCompleteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid bulk complete request", zap.Error(err))
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		requestLog(c, h.log).Warn("Invalid bulk complete request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
 		return
 	}
 
 	resp, err := h.taskService.BulkComplete(c.Request.Context(), userID.(string), req)
 	if err != nil {
-		h.log.Error("Failed to bulk complete tasks", zap.Error(err))
-		c.JSON(400, gin.H{"error": err.Error()})
+		requestLog(c, h.log).Error("Failed to bulk complete tasks", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
 		return
 	}
 
 	c.JSON(200, resp)
 }
+
+// Import godoc
+// @Summary Import tasks
+// @Description Import tasks from a CSV (with header row) or NDJSON file. Rows are validated individually and stored in a single transaction only if every row is valid.
+// @Tags tasks
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Import format (csv or ndjson); inferred from Content-Type when omitted"
+// @Param columns query string false "Column mapping as field:column pairs, e.g. title:Name,status:State"
+// @Param dry_run query bool false "Validate only and return the row-level report without storing tasks"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe; the first response is replayed"
+// @Success 200 {object} dto.TaskImportResponse
+// @Success 201 {object} dto.TaskImportResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 422 {object} dto.TaskImportResponse
+// @Security BearerAuth
+// @Router /api/v1/tasks/import [post]
+func (h *TaskHandler) Import(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	format, err := taskio.ResolveImportFormat(c.Query("format"), c.ContentType())
+	if err != nil {
+		middleware.AbortWithProblem(c, domain.InvalidInput(err.Error()))
+		return
+	}
+
+	mapping, err := taskio.ParseColumnMapping(c.Query("columns"))
+	if err != nil {
+		middleware.AbortWithProblem(c, domain.InvalidInput(err.Error()))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	// The router limits the body to maxImportBytes
+	rows, err := taskio.ParseImport(c.Request.Body, format, mapping)
+	if err != nil {
+		requestLog(c, h.log).Warn("Invalid import file", zap.Error(err))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			middleware.AbortWithProblem(c, domain.ErrRequestTooLarge)
+			return
+		}
+		middleware.AbortWithProblem(c, domain.InvalidInput(err.Error()))
+		return
+	}
+
+	resp, err := h.taskService.Import(c.Request.Context(), userID.(string), dto.TaskImportRequest{
+		Rows:   rows,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to import tasks", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	switch {
+	case resp.DryRun:
+		c.JSON(200, resp)
+	case resp.InvalidRows > 0:
+		c.JSON(422, resp)
+	default:
+		c.JSON(201, resp)
+	}
+}
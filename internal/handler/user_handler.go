@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/service"
+	"go.uber.org/zap"
+
+	"github.com/vedologic/task-manager/internal/middleware"
+)
+
+type UserHandler struct {
+	userService service.UserService
+	log         *zap.Logger
+}
+
+// NewUserHandler creates a new user profile handler
+func NewUserHandler(userService service.UserService, log *zap.Logger) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		log:         log,
+	}
+}
+
+// GetProfile godoc
+// @Summary Get current user profile
+// @Description Get the profile and account settings of the authenticated user
+// @Tags me
+// @Produce json
+// @Success 200 {object} dto.ProfileResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/me [get]
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	profile, err := h.userService.GetProfile(c.Request.Context(), userID.(string))
+	if err != nil {
+		requestLog(c, h.log).Error("Failed to get profile", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, profile)
+}
+
+// UpdateProfile godoc
+// @Summary Update current user profile
+// @Description Change the display name, IANA timezone or BCP 47 locale. Omitted fields are left unchanged
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateProfileRequest true "Profile changes"
+// @Success 200 {object} dto.ProfileResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/me [patch]
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid update profile request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	profile, err := h.userService.UpdateProfile(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Warn("Failed to update profile", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, profile)
+}
+
+// ChangeEmail godoc
+// @Summary Change email address
+// @Description Send a confirmation link to the new address. The email changes once the link is used via /api/v1/auth/confirm-email-change
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangeEmailRequest true "Email change request"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/me/email [post]
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid change email request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	req.AuthenticatedAt = c.GetTime("authenticated_at")
+
+	if err := h.userService.RequestEmailChange(c.Request.Context(), userID.(string), req); err != nil {
+		requestLog(c, h.log).Warn("Email change request failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{"message": "A confirmation link has been sent to the new email address"})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description Apply a pending email change with the single-use token sent to the new address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "Confirmation token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Router /api/v1/auth/confirm-email-change [post]
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid confirm email change request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	if err := h.userService.ConfirmEmailChange(c.Request.Context(), req); err != nil {
+		requestLog(c, h.log).Warn("Email change confirmation failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Email address changed"})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Set a new password after confirming the current one. All other sessions are signed out and a new token for this session is returned
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangePasswordRequest true "Password change request"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/me/password [post]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid change password request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	resp, err := h.userService.ChangePassword(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Warn("Password change failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	c.JSON(200, resp)
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Schedule the account and all of its data for deletion after a grace period and sign out everywhere. Signing in again before the deletion time restores the account
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param request body dto.DeleteAccountRequest true "Password confirmation"
+// @Success 202 {object} dto.AccountDeletionResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Security BearerAuth
+// @Router /api/v1/me [delete]
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c, h.log).Warn("Invalid delete account request", zap.Error(err))
+		middleware.AbortWithProblem(c, bindError(err))
+		return
+	}
+
+	req.AuthenticatedAt = c.GetTime("authenticated_at")
+
+	resp, err := h.userService.DeleteAccount(c.Request.Context(), userID.(string), req)
+	if err != nil {
+		requestLog(c, h.log).Warn("Account deletion failed", zap.Error(err))
+		middleware.AbortWithProblem(c, err)
+		return
+	}
+
+	requestLog(c, h.log).Info("Account scheduled for deletion", zap.String("user_id", userID.(string)))
+	c.JSON(202, resp)
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is where metrics are served
+const Path = "/metrics"
+
+// namespace prefixes the application's own metrics
+const namespace = "task_manager"
+
+// Registry holds every metric exposed on /metrics, including Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+// HTTP metrics, labelled by route template rather than raw path to keep cardinality bounded
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+)
+
+// Schema metrics
+var (
+	SchemaVersion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "schema_version",
+		Help:      "Current database migration version.",
+	})
+
+	SchemaDirty = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "schema_dirty",
+		Help:      "1 if the last migration failed and the schema needs manual repair, 0 otherwise.",
+	})
+)
+
+// Database resilience metrics
+var (
+	DBRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_retries_total",
+		Help:      "Total number of database operations retried after a transient error, by operation and error class.",
+	}, []string{"operation", "class"})
+
+	DBCircuitBreakerRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_circuit_breaker_rejections_total",
+		Help:      "Total number of database operations failed fast by the open circuit breaker, by operation.",
+	}, []string{"operation"})
+
+	DBCircuitBreakerState = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_circuit_breaker_state",
+		Help:      "State of the database circuit breaker: 0 closed, 1 half-open, 2 open.",
+	})
+)
+
+// Business metrics
+var (
+	TasksCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Total number of tasks created, by source (api or import).",
+	}, []string{"source"})
+
+	TasksCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_completed_total",
+		Help:      "Total number of tasks moved to the done status.",
+	})
+
+	TaskBulkCompleteFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_bulk_complete_failures_total",
+		Help:      "Total number of tasks that could not be completed by a bulk complete request.",
+	})
+)
+
+// Task creation sources
+const (
+	SourceAPI    = "api"
+	SourceImport = "import"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		SchemaVersion,
+		SchemaDirty,
+		DBRetries,
+		DBCircuitBreakerRejections,
+		DBCircuitBreakerState,
+		TasksCreated,
+		TasksCompleted,
+		TaskBulkCompleteFailures,
+	)
+
+	// Export both sources from the start so rates work before the first task is created
+	TasksCreated.WithLabelValues(SourceAPI)
+	TasksCreated.WithLabelValues(SourceImport)
+}
+
+// RegisterDBStats exposes the connection pool statistics of db, labelled with dbName
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// SetSchemaVersion records the migration version the database is at
+func SetSchemaVersion(version uint, dirty bool) {
+	SchemaVersion.Set(float64(version))
+	if dirty {
+		SchemaDirty.Set(1)
+	} else {
+		SchemaDirty.Set(0)
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
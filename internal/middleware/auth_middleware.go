@@ -1,51 +1,151 @@
 package middleware
 
 import (
+	"context"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
 	"github.com/vedologic/task-manager/pkg/utils"
 )
 
-// AuthMiddleware returns a gin middleware for JWT authentication
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// UserLookup loads the current state of an authenticated user
+type UserLookup interface {
+	// GetActiveUser returns the user, or an error if it does not exist or is disabled
+	GetActiveUser(ctx context.Context, userID string) (*domain.User, error)
+}
+
+// TokenAuthenticator resolves personal access tokens
+type TokenAuthenticator interface {
+	// Authenticate returns the token owner and the token, or an error if the token is not usable
+	Authenticate(ctx context.Context, raw string) (*domain.User, *domain.PersonalAccessToken, error)
+}
+
+// Authentication methods stored in the request context under "auth_method"
+const (
+	AuthMethodJWT = "jwt"
+	AuthMethodPAT = "pat"
+)
+
+// AuthMiddleware returns a gin middleware for JWT and personal access token authentication.
+// The user is re-checked on every request so disabled accounts and role changes take effect immediately.
+func AuthMiddleware(jwtSecret string, users UserLookup, tokens TokenAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{
-				"error": "Authorization header is required",
-			})
-			c.Abort()
+			AbortWithProblem(c, domain.ErrAuthenticationRequired)
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(401, gin.H{
-				"error": "Invalid authorization header format",
-			})
-			c.Abort()
+			AbortWithProblem(c, domain.ErrInvalidAuthHeader)
 			return
 		}
 
 		token := parts[1]
 
+		// Personal access tokens carry their own scopes
+		if strings.HasPrefix(token, domain.AccessTokenPrefix) {
+			user, pat, err := tokens.Authenticate(c.Request.Context(), token)
+			if err != nil {
+				AbortWithProblem(c, domain.ErrInvalidAccessToken)
+				return
+			}
+
+			// Writes made with the token keep the owner's later reads on the primary
+			c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), strconv.Itoa(user.ID)))
+			c.Set("user_id", strconv.Itoa(user.ID))
+			c.Set("email", user.Email)
+			c.Set("role", string(user.Role))
+			c.Set("auth_method", AuthMethodPAT)
+			c.Set("scopes", pat.ScopeList())
+			c.Set("token_id", strconv.Itoa(pat.ID))
+
+			c.Next()
+			return
+		}
+
 		// Validate token
 		claims, err := utils.ValidateToken(token, jwtSecret)
 		if err != nil {
-			c.JSON(401, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			AbortWithProblem(c, domain.ErrInvalidAccessToken)
+			return
+		}
+
+		// Tag the request before the lookup so a user's own recent writes are read from the primary
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), claims.UserID))
+
+		// A password change or deletion request bumps the session version and revokes older tokens
+		user, err := users.GetActiveUser(c.Request.Context(), claims.UserID)
+		if err != nil || claims.SessionVersion != user.SessionVersion {
+			AbortWithProblem(c, domain.ErrInvalidAccessToken)
 			return
 		}
 
 		// Set user context
 		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
+		c.Set("email", user.Email)
+		c.Set("role", string(user.Role))
+		c.Set("auth_method", AuthMethodJWT)
+		if claims.IssuedAt != nil {
+			c.Set("authenticated_at", claims.IssuedAt.Time)
+		}
 
 		c.Next()
 	}
 }
+
+// RequireScope returns a gin middleware that requires a personal access token to carry the scope.
+// Interactive (JWT) sessions have every scope. It must run after AuthMiddleware.
+func RequireScope(scope domain.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodPAT {
+			c.Next()
+			return
+		}
+
+		for _, granted := range c.GetStringSlice("scopes") {
+			if granted == string(scope) {
+				c.Next()
+				return
+			}
+		}
+
+		AbortWithProblem(c, domain.ErrInsufficientScope.WithMessage("Token is missing required scope: "+string(scope)))
+	}
+}
+
+// RequireJWT returns a gin middleware that rejects personal access tokens,
+// for routes such as token management and administration. It must run after AuthMiddleware.
+func RequireJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodJWT {
+			c.Next()
+			return
+		}
+
+		AbortWithProblem(c, domain.ErrSessionRequired)
+	}
+}
+
+// RequireRole returns a gin middleware that allows only users with one of the given roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...domain.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := domain.UserRole(c.GetString("role"))
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		AbortWithProblem(c, domain.ErrInsufficientPermissions)
+	}
+}
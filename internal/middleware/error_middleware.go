@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix makes the problem type a stable URI derived from the error code
+const problemTypePrefix = "urn:task-manager:problem:"
+
+// errInternal is reported for errors that are not domain errors; the cause is only logged
+var errInternal = domain.NewError(errors.New("internal"), "internal_error", "An unexpected error occurred")
+
+// databaseRetryAfter is suggested to clients when the database failed transiently
+const databaseRetryAfter = 5 * time.Second
+
+// statusByKind maps domain error kinds to HTTP status codes
+var statusByKind = []struct {
+	kind   error
+	status int
+}{
+	{domain.ErrInvalidInput, http.StatusBadRequest},
+	{domain.ErrUnauthorized, http.StatusUnauthorized},
+	{domain.ErrForbidden, http.StatusForbidden},
+	{domain.ErrNotFound, http.StatusNotFound},
+	{domain.ErrConflict, http.StatusConflict},
+	{domain.ErrUnprocessable, http.StatusUnprocessableEntity},
+	{domain.ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{domain.ErrTooManyRequests, http.StatusTooManyRequests},
+	{domain.ErrUpstream, http.StatusBadGateway},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// ErrorHandler returns a gin middleware that turns the error recorded with AbortWithProblem
+// into an application/problem+json response. Errors that are not domain errors become a 500
+// with a generic message, or a 503 when the database failed transiently, and are logged. It must run before every middleware that can fail.
+func ErrorHandler(log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		var domainErr *domain.Error
+		if !errors.As(err, &domainErr) {
+			logger.WithContext(c.Request.Context(), log).Error("Request failed",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(err),
+			)
+		}
+
+		// A middleware such as Idempotency may already have written the problem
+		if !c.Writer.Written() {
+			respondProblem(c, err)
+		}
+	}
+}
+
+// AbortWithProblem stops the handler chain and records err for ErrorHandler to report
+func AbortWithProblem(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// renderRecordedProblem writes the problem for the last recorded error unless a response was written.
+// Middlewares that need the final response before ErrorHandler runs call it after c.Next.
+func renderRecordedProblem(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	respondProblem(c, c.Errors.Last().Err)
+}
+
+// respondProblem writes err as application/problem+json
+func respondProblem(c *gin.Context, err error) {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		domainErr = errInternal
+		if database.IsTransient(err) {
+			domainErr = domain.ErrDatabaseUnavailable.WithRetryAfter(databaseRetryAfter)
+		}
+	}
+
+	status := http.StatusInternalServerError
+	for _, m := range statusByKind {
+		if errors.Is(domainErr.Kind, m.kind) {
+			status = m.status
+			break
+		}
+	}
+
+	if domainErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(domainErr.RetryAfter)))
+	}
+
+	// gin keeps an explicitly set Content-Type when rendering JSON
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, dto.Problem{
+		Type:     problemTypePrefix + domainErr.Code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   domainErr.Message,
+		Instance: c.Request.URL.Path,
+		Code:     domainErr.Code,
+		Errors:   domainErr.Fields,
+	})
+}
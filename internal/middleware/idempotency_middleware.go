@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// IdempotencyKeyHeader is the request header that makes a mutating request safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength matches the key column of the idempotency_keys table
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentResponseSize bounds the response stored for replay; larger responses are not stored
+const maxIdempotentResponseSize = 1 << 20
+
+// IdempotencyStore records idempotency keys and their responses
+type IdempotencyStore interface {
+	// Claim reserves a key for a new request, or returns the existing record if the key is in use
+	Claim(ctx context.Context, record *domain.IdempotencyKey) (*domain.IdempotencyKey, error)
+
+	// Complete stores the response of the request that claimed the key
+	Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error
+
+	// Release deletes an unfinished claim so the request can be retried
+	Release(ctx context.Context, userID int, key string) error
+}
+
+// Idempotency returns a gin middleware that honours the Idempotency-Key header. The first request
+// with a key runs normally and its response is stored for ttl; retries with the same key and request
+// get the stored response, and reusing the key for a different request is rejected with 422.
+// Keys are scoped to the user, so it must run after AuthMiddleware.
+func Idempotency(store IdempotencyStore, ttl time.Duration, log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			AbortWithProblem(c, domain.InvalidInput("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.GetString("user_id"))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// Routes bound the body with MaxBodySize, since it is buffered here
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				AbortWithProblem(c, domain.ErrRequestTooLarge)
+				return
+			}
+			AbortWithProblem(c, domain.InvalidInput("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c, body)
+		now := time.Now()
+		existing, err := store.Claim(c.Request.Context(), &domain.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			AbortWithProblem(c, fmt.Errorf("failed to claim idempotency key: %w", err))
+			return
+		}
+
+		if existing != nil {
+			replayIdempotent(c, existing, fingerprint)
+			return
+		}
+
+		// The claim is released unless a response is stored, including when the handler panics,
+		// so a failed request can be retried with the same key
+		completed := false
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, userID, key); err != nil {
+				logger.WithContext(ctx, log).Warn("Failed to release idempotency key", zap.Error(err))
+			}
+		}()
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// Errors are rendered here rather than by ErrorHandler so the stored response includes them
+		renderRecordedProblem(c)
+
+		status := writer.Status()
+		if status >= 500 || writer.overflow {
+			return
+		}
+
+		if err := store.Complete(ctx, userID, key, status, writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			logger.WithContext(ctx, log).Warn("Failed to store idempotent response", zap.Error(err))
+			return
+		}
+		completed = true
+	}
+}
+
+// replayIdempotent answers a request whose key is already in use
+func replayIdempotent(c *gin.Context, existing *domain.IdempotencyKey, fingerprint string) {
+	if existing.RequestHash != fingerprint {
+		AbortWithProblem(c, domain.ErrIdempotencyKeyReuse)
+		return
+	}
+
+	if !existing.IsCompleted() {
+		AbortWithProblem(c, domain.ErrIdempotencyPending)
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	if len(existing.ResponseBody) == 0 {
+		c.Status(*existing.StatusCode)
+	} else {
+		c.Data(*existing.StatusCode, existing.ContentType, existing.ResponseBody)
+	}
+	c.Abort()
+}
+
+// requestFingerprint hashes the parts of a request that must match for a retry to be replayed
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// capturingWriter copies the response body so it can be stored for replay
+type capturingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+// Write sends b to the client and keeps a copy while the response is small enough to store
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString sends s to the client and keeps a copy while the response is small enough to store
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture appends b to the stored copy until maxIdempotentResponseSize is exceeded
+func (w *capturingWriter) capture(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > maxIdempotentResponseSize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/domain"
+	"go.uber.org/zap"
+)
+
+// memoryIdempotencyStore keeps idempotency keys in a map
+type memoryIdempotencyStore struct {
+	mu       sync.Mutex
+	records  map[string]domain.IdempotencyKey
+	released int
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]domain.IdempotencyKey)}
+}
+
+func storeKey(userID int, key string) string {
+	return fmt.Sprintf("%d/%s", userID, key)
+}
+
+func (s *memoryIdempotencyStore) Claim(_ context.Context, record *domain.IdempotencyKey) (*domain.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[storeKey(record.UserID, record.Key)]; ok {
+		return &existing, nil
+	}
+	s.records[storeKey(record.UserID, record.Key)] = *record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[storeKey(userID, key)]
+	record.StatusCode = &statusCode
+	record.ContentType = contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	s.records[storeKey(userID, key)] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, userID int, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, storeKey(userID, key))
+	s.released++
+	return nil
+}
+
+// idempotentRouter serves POST /tasks, answering with status and counting the requests it handles
+func idempotentRouter(store IdempotencyStore, status *int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler(zap.NewNop()))
+	r.POST("/tasks",
+		func(c *gin.Context) {
+			c.Set("user_id", "42")
+		},
+		MaxBodySize(64),
+		Idempotency(store, time.Hour, zap.NewNop()),
+		func(c *gin.Context) {
+			*calls++
+			c.JSON(*status, gin.H{"call": *calls})
+		},
+	)
+	return r
+}
+
+func postTask(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysCompletedRequest(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusCreated, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	first := postTask(r, "key-1", `{"title":"a"}`)
+	second := postTask(r, "key-1", `{"title":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("statuses = %d, %d, want %d twice", first.Code, second.Code, http.StatusCreated)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("replayed body = %q, want %q", second.Body.String(), first.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response is missing the Idempotent-Replayed header")
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("original response has the Idempotent-Replayed header")
+	}
+}
+
+func TestIdempotencyRejectsKeyReusedForDifferentRequest(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusCreated, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	postTask(r, "key-1", `{"title":"a"}`)
+	w := postTask(r, "key-1", `{"title":"b"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if !strings.Contains(w.Body.String(), "idempotency_key_reused") {
+		t.Errorf("body = %s, want the idempotency_key_reused code", w.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsRequestStillInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusCreated, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	// Claim the key as a concurrent request would, without completing it
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"a"}`))
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	_, _ = store.Claim(context.Background(), &domain.IdempotencyKey{
+		UserID:      42,
+		Key:         "key-1",
+		RequestHash: requestFingerprint(c, []byte(`{"title":"a"}`)),
+	})
+
+	w := postTask(r, "key-1", `{"title":"a"}`)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times, want 0", calls)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusInternalServerError, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	if w := postTask(r, "key-1", `{"title":"a"}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if store.released != 1 || len(store.records) != 0 {
+		t.Fatalf("released = %d, records = %d, want the claim released", store.released, len(store.records))
+	}
+
+	status = http.StatusCreated
+	w := postTask(r, "key-1", `{"title":"a"}`)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("retry status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+	if w.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("retry after a server error was replayed")
+	}
+}
+
+func TestIdempotencyStoresClientErrors(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusBadRequest, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	postTask(r, "key-1", `{"title":"a"}`)
+	w := postTask(r, "key-1", `{"title":"a"}`)
+
+	if w.Code != http.StatusBadRequest || calls != 1 {
+		t.Errorf("status = %d after %d calls, want %d replayed after 1 call", w.Code, calls, http.StatusBadRequest)
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusCreated, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	postTask(r, "", `{"title":"a"}`)
+	postTask(r, "", `{"title":"a"}`)
+
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+	if len(store.records) != 0 {
+		t.Errorf("stored %d records, want none", len(store.records))
+	}
+}
+
+func TestIdempotencyRejectsOversizedBody(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status, calls := http.StatusCreated, 0
+	r := idempotentRouter(store, &status, &calls)
+
+	w := postTask(r, "key-1", `{"title":"`+strings.Repeat("a", 100)+`"}`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if calls != 0 || len(store.records) != 0 {
+		t.Errorf("calls = %d, records = %d, want the request rejected before the claim", calls, len(store.records))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/pkg/logger"
 	"go.uber.org/zap"
 )
 
@@ -23,7 +24,7 @@ func LoggerMiddleware(log *zap.Logger) gin.HandlerFunc {
 		method := c.Request.Method
 		path := c.Request.URL.Path
 
-		log.Info("HTTP Request",
+		logger.WithContext(c.Request.Context(), log).Info("HTTP Request",
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.Int("status", statusCode),
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, so unknown paths cannot inflate cardinality
+const unmatchedRoute = "unmatched"
+
+// Metrics returns a gin middleware that records request counts and latency per route template
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(startTime).Seconds())
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/pkg/logger"
 	"go.uber.org/zap"
 )
 
@@ -10,14 +11,13 @@ func RecoveryMiddleware(log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Error("Panic recovered",
+				logger.WithContext(c.Request.Context(), log).Error("Panic recovered",
 					zap.String("method", c.Request.Method),
 					zap.String("path", c.Request.URL.Path),
 					zap.Any("error", err),
 				)
-				c.JSON(500, gin.H{
-					"error": "Internal server error",
-				})
+				c.Abort()
+				respondProblem(c, errInternal)
 			}
 		}()
 		c.Next()
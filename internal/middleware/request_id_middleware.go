@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID from the client and back in the response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs so they are safe to log
+const maxRequestIDLength = 128
+
+// RequestID returns a gin middleware that keeps the client's X-Request-ID, or generates one,
+// echoes it in the response and stores it in the request context for logging and tracing
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = tracing.NewRequestID()
+		}
+
+		ctx := tracing.ContextWithRequestID(c.Request.Context(), id)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request.id", id))
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// isValidRequestID accepts IDs of printable ASCII without spaces, such as UUIDs
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vedologic/task-manager/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths are polled by infrastructure and would only add noise to traces
+var untracedPaths = map[string]bool{
+	"/health":    true,
+	"/livez":     true,
+	"/readyz":    true,
+	metrics.Path: true,
+}
+
+// Tracing returns a gin middleware that continues the W3C trace context of the request, if any,
+// and records a server span named after the route. Probes, metrics scrapes and API docs are not traced.
+func Tracing(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		path := c.Request.URL.Path
+		return !untracedPaths[path] && !strings.HasPrefix(path, "/swagger/")
+	}))
+}
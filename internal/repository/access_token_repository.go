@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// accessTokenRepository implements AccessTokenRepository interface using raw SQL
+type accessTokenRepository struct {
+	db *database.Router
+}
+
+// NewAccessTokenRepository creates a new personal access token repository instance
+func NewAccessTokenRepository(db *database.Router) AccessTokenRepository {
+	return &accessTokenRepository{
+		db: db,
+	}
+}
+
+// lastUsedResolution limits how often last_used_at is written for a busy token
+const lastUsedResolution = time.Minute
+
+// SQL Queries
+const (
+	queryCreateAccessToken = `
+		INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	queryFindAccessTokenByHash = `
+		SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = $1
+	`
+
+	queryFindAccessTokensByUserID = `
+		SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	queryRevokeAccessToken = `
+		UPDATE personal_access_tokens
+		SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`
+
+	queryTouchAccessToken = `
+		UPDATE personal_access_tokens
+		SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+)
+
+// Create stores a new personal access token
+func (r *accessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		queryCreateAccessToken,
+		token.UserID,
+		token.Name,
+		token.TokenPrefix,
+		token.TokenHash,
+		token.Scopes,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return nil
+}
+
+// FindByHash finds a token by the hash of its secret
+func (r *accessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	token := &domain.PersonalAccessToken{}
+
+	err := sqlx.GetContext(ctx, r.db.Primary(ctx), token, queryFindAccessTokenByHash, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAccessTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to find access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// FindByUserID finds all non-revoked tokens of a user
+func (r *accessTokenRepository) FindByUserID(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error) {
+	tokens := []domain.PersonalAccessToken{}
+
+	err := sqlx.SelectContext(ctx, r.db.Reader(ctx), &tokens, queryFindAccessTokensByUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find access tokens by user id: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke revokes a token owned by the user
+func (r *accessTokenRepository) Revoke(ctx context.Context, id string, userID string) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryRevokeAccessToken, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records token usage, writing at most once per lastUsedResolution
+func (r *accessTokenRepository) TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	_, err := r.db.Writer(ctx).ExecContext(ctx, queryTouchAccessToken, usedAt, id, usedAt.Add(-lastUsedResolution))
+	if err != nil {
+		return fmt.Errorf("failed to update access token last used: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// auditLogRepository implements AuditLogRepository interface using raw SQL
+type auditLogRepository struct {
+	db *database.Router
+}
+
+// NewAuditLogRepository creates a new audit log repository instance
+func NewAuditLogRepository(db *database.Router) AuditLogRepository {
+	return &auditLogRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	queryCreateAuditLog = `
+		INSERT INTO audit_logs (actor_user_id, action, target_type, target_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	queryListAuditLogs = `
+		SELECT id, actor_user_id, action, target_type, target_id, details, created_at
+		FROM audit_logs
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	queryCountAuditLogs = `
+		SELECT COUNT(*) FROM audit_logs
+	`
+)
+
+// Create records an audit log entry
+func (r *auditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	if entry.Details == "" {
+		entry.Details = "{}"
+	}
+
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		queryCreateAuditLog,
+		entry.ActorUserID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.Details,
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// List returns a page of audit log entries, newest first
+func (r *auditLogRepository) List(ctx context.Context, page, limit int) ([]domain.AuditLog, int64, error) {
+	var total int64
+	if err := sqlx.GetContext(ctx, r.db.Reader(ctx), &total, queryCountAuditLogs); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	entries := []domain.AuditLog{}
+	offset := (page - 1) * limit
+	if err := sqlx.SelectContext(ctx, r.db.Reader(ctx), &entries, queryListAuditLogs, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// idempotencyKeyRepository implements IdempotencyKeyRepository interface using raw SQL
+type idempotencyKeyRepository struct {
+	db *database.Router
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository instance
+func NewIdempotencyKeyRepository(db *database.Router) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	// An expired key is taken over as if it were new; a live one is left untouched and no row is returned
+	queryClaimIdempotencyKey = `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = '',
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING user_id
+	`
+
+	queryFindIdempotencyKey = `
+		SELECT user_id, key, request_hash, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`
+
+	queryCompleteIdempotencyKey = `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3
+		WHERE user_id = $4 AND key = $5 AND status_code IS NULL
+	`
+
+	queryReleaseIdempotencyKey = `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND status_code IS NULL
+	`
+
+	queryDeleteExpiredIdempotencyKeys = `
+		DELETE FROM idempotency_keys
+		WHERE expires_at < $1
+	`
+)
+
+// Claim reserves a key for a new request. It returns nil if the key was claimed, or the
+// existing record if the key is already in use.
+func (r *idempotencyKeyRepository) Claim(ctx context.Context, record *domain.IdempotencyKey) (*domain.IdempotencyKey, error) {
+	// A concurrent release can remove the existing record between the two statements, so try twice
+	for attempt := 0; attempt < 2; attempt++ {
+		var userID int
+		err := sqlx.GetContext(
+			ctx,
+			r.db.Writer(ctx),
+			&userID,
+			queryClaimIdempotencyKey,
+			record.UserID,
+			record.Key,
+			record.RequestHash,
+			record.CreatedAt,
+			record.ExpiresAt,
+		)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		existing := &domain.IdempotencyKey{}
+		err = sqlx.GetContext(ctx, r.db.Primary(ctx), existing, queryFindIdempotencyKey, record.UserID, record.Key)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+		}
+	}
+
+	return nil, errors.New("idempotency key is changing concurrently")
+}
+
+// Complete stores the response of the request that claimed the key
+func (r *idempotencyKeyRepository) Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	_, err := r.db.Writer(ctx).ExecContext(ctx, queryCompleteIdempotencyKey, statusCode, contentType, body, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release deletes an unfinished claim so the request can be retried
+func (r *idempotencyKeyRepository) Release(ctx context.Context, userID int, key string) error {
+	_, err := r.db.Writer(ctx).ExecContext(ctx, queryReleaseIdempotencyKey, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes keys whose replay window has ended
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteExpiredIdempotencyKeys, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
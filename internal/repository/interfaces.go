@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/ratelimit"
 )
 
 // UserRepository defines the interface for user data operations
@@ -19,6 +21,67 @@ type UserRepository interface {
 
 	// ExistsByEmail checks if a user with the given email exists
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+
+	// List returns a page of users and the total number of users
+	List(ctx context.Context, page, limit int) ([]domain.User, int64, error)
+
+	// UpdateRole changes the role of a user
+	UpdateRole(ctx context.Context, id string, role domain.UserRole) error
+
+	// SetDisabledAt disables (non-nil) or re-enables (nil) a user account
+	SetDisabledAt(ctx context.Context, id string, disabledAt *time.Time) error
+
+	// MarkEmailVerified records that the user verified their email address
+	MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error
+
+	// UpdatePassword replaces the password hash of a user
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+
+	// UpdatePasswordAndRevokeSessions replaces the password hash, invalidates issued sessions and returns the new session version
+	UpdatePasswordAndRevokeSessions(ctx context.Context, id string, passwordHash string) (int, error)
+
+	// UpdateProfile saves the display name, timezone and locale of a user
+	UpdateProfile(ctx context.Context, user *domain.User) error
+
+	// SetPendingEmail records a requested email change, or cancels it when email is nil
+	SetPendingEmail(ctx context.Context, id string, email *string) error
+
+	// ApplyEmailChange replaces the email with the pending address if it still equals email
+	ApplyEmailChange(ctx context.Context, id string, email string, verifiedAt time.Time) (bool, error)
+
+	// ScheduleDeletion marks the account for deletion at the given time and invalidates issued sessions
+	ScheduleDeletion(ctx context.Context, id string, deleteAt time.Time) error
+
+	// CancelDeletion clears a scheduled deletion; it returns false if none was scheduled
+	CancelDeletion(ctx context.Context, id string) (bool, error)
+
+	// DeleteScheduled permanently deletes users whose deletion time has passed
+	DeleteScheduled(ctx context.Context, now time.Time) (int64, error)
+
+	// SetTOTPSecret stores a pending TOTP secret, or clears two-factor authentication when secret is nil
+	SetTOTPSecret(ctx context.Context, id string, secret *string) error
+
+	// EnableTOTP activates the pending TOTP secret
+	EnableTOTP(ctx context.Context, id string, enabledAt time.Time) error
+
+	// UseTOTPStep records a used TOTP time step; it returns false if the code was already used
+	UseTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+
+	// FindByIdentity finds the user linked to an external identity, or returns nil if none is linked
+	FindByIdentity(ctx context.Context, issuer, subject string) (*domain.User, error)
+
+	// UpsertIdentity links an external identity to a user or records a new login for an existing link
+	UpsertIdentity(ctx context.Context, identity *domain.UserIdentity) error
+
+	// CreateWithIdentity provisions a new user linked to an external identity
+	CreateWithIdentity(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error
+}
+
+// TaskExportFilter narrows the tasks streamed by StreamByUserID
+type TaskExportFilter struct {
+	Status      string
+	Sort        domain.TaskSort
+	WithDueDate bool
 }
 
 // TaskRepository defines the interface for task data operations
@@ -26,11 +89,17 @@ type TaskRepository interface {
 	// Create creates a new task
 	Create(ctx context.Context, task *domain.Task) error
 
+	// CreateBatch inserts many tasks in a single transaction
+	CreateBatch(ctx context.Context, tasks []domain.Task) (int64, error)
+
 	// FindByID finds a task by ID
 	FindByID(ctx context.Context, id string) (*domain.Task, error)
 
-	// FindByUserID finds all tasks for a user with filtering and pagination
-	FindByUserID(ctx context.Context, userID string, page, limit int, status string) ([]domain.Task, int64, error)
+	// FindByUserID finds all tasks for a user with filtering, sorting and pagination
+	FindByUserID(ctx context.Context, userID string, page, limit int, status string, sort domain.TaskSort) ([]domain.Task, int64, error)
+
+	// StreamByUserID walks a user's tasks through a server-side cursor, calling fn for each row
+	StreamByUserID(ctx context.Context, userID string, filter TaskExportFilter, fn func(*domain.Task) error) error
 
 	// Update updates a task
 	Update(ctx context.Context, task *domain.Task) error
@@ -43,4 +112,124 @@ type TaskRepository interface {
 
 	// ExistsByID checks if a task exists and belongs to the user
 	ExistsByID(ctx context.Context, id string, userID string) (bool, error)
+
+	// GetStats aggregates task counts, timeline and completion metrics for a user
+	GetStats(ctx context.Context, userID string, from, to time.Time, interval string) (*domain.TaskStats, error)
+
+	// TransferOwnership moves tasks from one user to another; all tasks are moved when taskIDs is empty
+	TransferOwnership(ctx context.Context, fromUserID, toUserID string, taskIDs []string) (int64, error)
+}
+
+// SavedViewRepository defines the interface for saved view data operations
+type SavedViewRepository interface {
+	// Create creates a new saved view
+	Create(ctx context.Context, view *domain.SavedView) error
+
+	// FindByID finds a saved view owned by the user
+	FindByID(ctx context.Context, id string, userID string) (*domain.SavedView, error)
+
+	// FindByUserID finds all saved views for a user
+	FindByUserID(ctx context.Context, userID string) ([]domain.SavedView, error)
+
+	// Update updates a saved view
+	Update(ctx context.Context, view *domain.SavedView) error
+
+	// Delete deletes a saved view owned by the user
+	Delete(ctx context.Context, id string, userID string) error
+}
+
+// AuditLogRepository defines the interface for audit log data operations
+type AuditLogRepository interface {
+	// Create records an audit log entry
+	Create(ctx context.Context, entry *domain.AuditLog) error
+
+	// List returns a page of audit log entries, newest first, and the total count
+	List(ctx context.Context, page, limit int) ([]domain.AuditLog, int64, error)
+}
+
+// AccessTokenRepository defines the interface for personal access token data operations
+type AccessTokenRepository interface {
+	// Create stores a new token
+	Create(ctx context.Context, token *domain.PersonalAccessToken) error
+
+	// FindByHash finds a token by the hash of its secret
+	FindByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error)
+
+	// FindByUserID finds all non-revoked tokens of a user
+	FindByUserID(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error)
+
+	// Revoke revokes a token owned by the user
+	Revoke(ctx context.Context, id string, userID string) error
+
+	// TouchLastUsed records that the token was used
+	TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// UserTokenRepository defines the interface for single-use email token data operations
+type UserTokenRepository interface {
+	// Create stores a new token
+	Create(ctx context.Context, token *domain.UserToken) error
+
+	// Consume marks an unused, unexpired token as used and returns its owner's ID
+	Consume(ctx context.Context, tokenHash string, purpose domain.UserTokenPurpose, now time.Time) (int, error)
+
+	// InvalidateForUser marks all unused tokens of a user for the purpose as used
+	InvalidateForUser(ctx context.Context, userID int, purpose domain.UserTokenPurpose, now time.Time) error
+}
+
+// LoginThrottleRepository defines the interface for failed-login tracking
+type LoginThrottleRepository interface {
+	// Find returns the throttle state for a key, or nil if there have been no recent failures
+	Find(ctx context.Context, keyType domain.LoginThrottleKeyType, key string) (*domain.LoginThrottle, error)
+
+	// RecordFailure counts a failed login and returns the number of failures within the window
+	RecordFailure(ctx context.Context, keyType domain.LoginThrottleKeyType, key string, now time.Time, window time.Duration) (int, error)
+
+	// Lock blocks logins for the key until the given time
+	Lock(ctx context.Context, keyType domain.LoginThrottleKeyType, key string, until time.Time) error
+
+	// Reset clears the failures recorded for a key
+	Reset(ctx context.Context, keyType domain.LoginThrottleKeyType, key string) error
+
+	// DeleteStale removes throttles whose failures are older than failuresBefore and that are no longer locked
+	DeleteStale(ctx context.Context, failuresBefore, now time.Time) (int64, error)
+}
+
+// RecoveryCodeRepository defines the interface for two-factor recovery code data operations
+type RecoveryCodeRepository interface {
+	// ReplaceForUser deletes the user's recovery codes and stores new ones
+	ReplaceForUser(ctx context.Context, userID string, codeHashes []string) error
+
+	// Consume marks an unused recovery code as used; it returns false if no such code exists
+	Consume(ctx context.Context, userID string, codeHash string, now time.Time) (bool, error)
+
+	// CountUnused returns the number of recovery codes the user has left
+	CountUnused(ctx context.Context, userID string) (int, error)
+
+	// DeleteForUser deletes all recovery codes of a user
+	DeleteForUser(ctx context.Context, userID string) error
+}
+
+// RateLimitRepository stores rate limit token buckets shared across replicas; it implements ratelimit.Store
+type RateLimitRepository interface {
+	// Take removes one token from the bucket for key if one is available
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+
+	// DeleteIdle removes buckets that have not been used since before
+	DeleteIdle(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyKeyRepository defines the interface for idempotency key data operations
+type IdempotencyKeyRepository interface {
+	// Claim reserves a key for a new request, or returns the existing record if the key is in use
+	Claim(ctx context.Context, record *domain.IdempotencyKey) (*domain.IdempotencyKey, error)
+
+	// Complete stores the response of the request that claimed the key
+	Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error
+
+	// Release deletes an unfinished claim so the request can be retried
+	Release(ctx context.Context, userID int, key string) error
+
+	// DeleteExpired removes keys whose replay window has ended
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
 }
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// loginThrottleRepository implements LoginThrottleRepository interface using raw SQL
+type loginThrottleRepository struct {
+	db *database.Router
+}
+
+// NewLoginThrottleRepository creates a new login throttle repository instance
+func NewLoginThrottleRepository(db *database.Router) LoginThrottleRepository {
+	return &loginThrottleRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	queryFindLoginThrottle = `
+		SELECT key_type, key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE key_type = $1 AND key = $2
+	`
+
+	// Failures older than the window are forgotten; the upsert keeps concurrent failures from being lost
+	queryRecordLoginFailure = `
+		INSERT INTO login_throttles (key_type, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (key_type, key) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttles.last_failure_at < $4 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures
+	`
+
+	queryLockLoginThrottle = `
+		UPDATE login_throttles
+		SET locked_until = GREATEST(COALESCE(locked_until, $1), $1)
+		WHERE key_type = $2 AND key = $3
+	`
+
+	queryResetLoginThrottle = `
+		DELETE FROM login_throttles
+		WHERE key_type = $1 AND key = $2
+	`
+
+	queryDeleteStaleLoginThrottles = `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $2)
+	`
+)
+
+// Find returns the throttle state for a key, or nil if there have been no recent failures
+func (r *loginThrottleRepository) Find(ctx context.Context, keyType domain.LoginThrottleKeyType, key string) (*domain.LoginThrottle, error) {
+	throttle := &domain.LoginThrottle{}
+
+	err := sqlx.GetContext(ctx, r.db.Primary(ctx), throttle, queryFindLoginThrottle, keyType, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find login throttle: %w", err)
+	}
+
+	return throttle, nil
+}
+
+// RecordFailure counts a failed login and returns the number of failures within the window
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, keyType domain.LoginThrottleKeyType, key string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+
+	err := sqlx.GetContext(ctx, r.db.Writer(ctx), &failures, queryRecordLoginFailure, keyType, key, now, now.Add(-window))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return failures, nil
+}
+
+// Lock blocks logins for the key until the given time; an existing longer lock is kept
+func (r *loginThrottleRepository) Lock(ctx context.Context, keyType domain.LoginThrottleKeyType, key string, until time.Time) error {
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, queryLockLoginThrottle, until, keyType, key); err != nil {
+		return fmt.Errorf("failed to lock login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// Reset clears the failures recorded for a key
+func (r *loginThrottleRepository) Reset(ctx context.Context, keyType domain.LoginThrottleKeyType, key string) error {
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, queryResetLoginThrottle, keyType, key); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStale removes throttles whose failures are older than failuresBefore and that are no longer locked
+func (r *loginThrottleRepository) DeleteStale(ctx context.Context, failuresBefore, now time.Time) (int64, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteStaleLoginThrottles, failuresBefore, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/ratelimit"
+)
+
+// rateLimitRepository implements RateLimitRepository interface using raw SQL
+type rateLimitRepository struct {
+	db *database.Router
+}
+
+// NewRateLimitRepository creates a new rate limit repository instance
+func NewRateLimitRepository(db *database.Router) RateLimitRepository {
+	return &rateLimitRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	// Refills the bucket for the time since its last update and takes a token in one statement, using
+	// the database clock so replicas agree. No row is returned when the bucket has no token to take.
+	queryTakeRateLimitToken = `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+		VALUES ($1, $2::double precision - 1, now())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = LEAST($2::double precision,
+				b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.updated_at)::double precision) * $3::double precision) - 1,
+			updated_at = now()
+		WHERE LEAST($2::double precision,
+				b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.updated_at)::double precision) * $3::double precision) >= 1
+		RETURNING tokens
+	`
+
+	queryPeekRateLimitTokens = `
+		SELECT LEAST($2::double precision,
+			tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - updated_at)::double precision) * $3::double precision)
+		FROM rate_limit_buckets
+		WHERE key = $1
+	`
+
+	queryDeleteIdleRateLimitBuckets = `
+		DELETE FROM rate_limit_buckets
+		WHERE updated_at < $1
+	`
+)
+
+// Take removes one token from the bucket for key if one is available
+func (r *rateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	capacity := float64(limit.Requests)
+	rate := limit.RatePerSecond()
+
+	var tokens float64
+	err := sqlx.GetContext(ctx, r.db.Writer(ctx), &tokens, queryTakeRateLimitToken, key, capacity, rate)
+	if err == nil {
+		return ratelimit.NewResult(limit, true, tokens), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	// Denied: read the current level to report when the next token is available
+	err = sqlx.GetContext(ctx, r.db.Primary(ctx), &tokens, queryPeekRateLimitTokens, key, capacity, rate)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, fmt.Errorf("failed to read rate limit bucket: %w", err)
+	}
+
+	return ratelimit.NewResult(limit, false, tokens), nil
+}
+
+// DeleteIdle removes buckets not used since before; they would have refilled and start full when recreated
+func (r *rateLimitRepository) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteIdleRateLimitBuckets, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
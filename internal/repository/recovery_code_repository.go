@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// recoveryCodeRepository implements RecoveryCodeRepository interface using raw SQL
+type recoveryCodeRepository struct {
+	db *database.Router
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository instance
+func NewRecoveryCodeRepository(db *database.Router) RecoveryCodeRepository {
+	return &recoveryCodeRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	queryDeleteRecoveryCodes = `
+		DELETE FROM recovery_codes
+		WHERE user_id = $1
+	`
+
+	queryCreateRecoveryCode = `
+		INSERT INTO recovery_codes (user_id, code_hash, created_at)
+		VALUES ($1, $2, $3)
+	`
+
+	queryConsumeRecoveryCode = `
+		UPDATE recovery_codes
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+
+	queryCountUnusedRecoveryCodes = `
+		SELECT COUNT(*) FROM recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+)
+
+// ReplaceForUser deletes the user's recovery codes and stores new ones in a single transaction
+func (r *recoveryCodeRepository) ReplaceForUser(ctx context.Context, userID string, codeHashes []string) error {
+	r.db.MarkWrite(ctx)
+	return database.WithTransaction(ctx, r.db.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, queryDeleteRecoveryCodes, userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		now := time.Now()
+		for _, hash := range codeHashes {
+			if _, err := tx.ExecContext(ctx, queryCreateRecoveryCode, userID, hash, now); err != nil {
+				return fmt.Errorf("failed to create recovery code: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Consume marks an unused recovery code as used; it returns false if no such code exists
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID string, codeHash string, now time.Time) (bool, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryConsumeRecoveryCode, now, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CountUnused returns the number of recovery codes the user has left
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID string) (int, error) {
+	var count int
+
+	if err := sqlx.GetContext(ctx, r.db.Reader(ctx), &count, queryCountUnusedRecoveryCodes, userID); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteForUser deletes all recovery codes of a user
+func (r *recoveryCodeRepository) DeleteForUser(ctx context.Context, userID string) error {
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteRecoveryCodes, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// uniqueViolationCode is the PostgreSQL error code for unique constraint violations
+const uniqueViolationCode = "23505"
+
+// savedViewRepository implements SavedViewRepository interface using raw SQL
+type savedViewRepository struct {
+	db *database.Router
+}
+
+// NewSavedViewRepository creates a new saved view repository instance
+func NewSavedViewRepository(db *database.Router) SavedViewRepository {
+	return &savedViewRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	queryCreateSavedView = `
+		INSERT INTO saved_views (user_id, name, status, sort, page_size, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	queryFindSavedViewByID = `
+		SELECT id, user_id, name, status, sort, page_size, created_at, updated_at
+		FROM saved_views
+		WHERE id = $1 AND user_id = $2
+	`
+
+	queryFindSavedViewsByUserID = `
+		SELECT id, user_id, name, status, sort, page_size, created_at, updated_at
+		FROM saved_views
+		WHERE user_id = $1
+		ORDER BY name
+	`
+
+	queryUpdateSavedView = `
+		UPDATE saved_views
+		SET name = $1, status = $2, sort = $3, page_size = $4, updated_at = $5
+		WHERE id = $6 AND user_id = $7
+	`
+
+	queryDeleteSavedView = `
+		DELETE FROM saved_views
+		WHERE id = $1 AND user_id = $2
+	`
+)
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// Create creates a new saved view in the database
+func (r *savedViewRepository) Create(ctx context.Context, view *domain.SavedView) error {
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		queryCreateSavedView,
+		view.UserID,
+		view.Name,
+		view.Status,
+		view.Sort,
+		view.PageSize,
+		view.CreatedAt,
+		view.UpdatedAt,
+	).Scan(&view.ID)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrSavedViewNameTaken
+		}
+		return fmt.Errorf("failed to create saved view: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID finds a saved view by ID for the owning user
+func (r *savedViewRepository) FindByID(ctx context.Context, id string, userID string) (*domain.SavedView, error) {
+	view := &domain.SavedView{}
+
+	err := sqlx.GetContext(ctx, r.db.Reader(ctx), view, queryFindSavedViewByID, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSavedViewNotFound
+		}
+		return nil, fmt.Errorf("failed to find saved view by id: %w", err)
+	}
+
+	return view, nil
+}
+
+// FindByUserID finds all saved views for a user ordered by name
+func (r *savedViewRepository) FindByUserID(ctx context.Context, userID string) ([]domain.SavedView, error) {
+	views := []domain.SavedView{}
+
+	err := sqlx.SelectContext(ctx, r.db.Reader(ctx), &views, queryFindSavedViewsByUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find saved views by user id: %w", err)
+	}
+
+	return views, nil
+}
+
+// Update updates an existing saved view
+func (r *savedViewRepository) Update(ctx context.Context, view *domain.SavedView) error {
+	result, err := r.db.Writer(ctx).ExecContext(
+		ctx,
+		queryUpdateSavedView,
+		view.Name,
+		view.Status,
+		view.Sort,
+		view.PageSize,
+		view.UpdatedAt,
+		view.ID,
+		view.UserID,
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrSavedViewNameTaken
+		}
+		return fmt.Errorf("failed to update saved view: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSavedViewNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a saved view (owned by user)
+func (r *savedViewRepository) Delete(ctx context.Context, id string, userID string) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteSavedView, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved view: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSavedViewNotFound
+	}
+
+	return nil
+}
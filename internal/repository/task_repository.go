@@ -5,19 +5,23 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/tracing"
 )
 
-// taskRepository implements TaskRepository interface using raw SQL
+// taskRepository implements TaskRepository interface using raw SQL. Lookups, listings and stats
+// may be served by a read replica; writes and transactions use the primary.
 type taskRepository struct {
-	db *sqlx.DB
+	db *database.Router
 }
 
 // NewTaskRepository creates a new task repository instance
-func NewTaskRepository(db *sqlx.DB) TaskRepository {
+func NewTaskRepository(db *database.Router) TaskRepository {
 	return &taskRepository{
 		db: db,
 	}
@@ -26,33 +30,33 @@ func NewTaskRepository(db *sqlx.DB) TaskRepository {
 // SQL Queries
 const (
 	queryCreateTask = `
-		INSERT INTO tasks (user_id, title, description, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO tasks (user_id, title, description, status, due_date, completed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id
 	`
 
 	queryFindTaskByID = `
-		SELECT id, user_id, title, description, status, created_at, updated_at
+		SELECT id, user_id, title, description, status, due_date, completed_at, created_at, updated_at
 		FROM tasks
 		WHERE id = $1
 	`
 
 	queryFindTasksByUserID = `
-		SELECT id, user_id, title, description, status, created_at, updated_at
+		SELECT id, user_id, title, description, status, due_date, completed_at, created_at, updated_at
 		FROM tasks
 		WHERE user_id = $1
 	`
 
 	queryFindTasksByUserIDWithStatus = `
-		SELECT id, user_id, title, description, status, created_at, updated_at
+		SELECT id, user_id, title, description, status, due_date, completed_at, created_at, updated_at
 		FROM tasks
 		WHERE user_id = $1 AND status = $2
 	`
 
 	queryUpdateTask = `
 		UPDATE tasks
-		SET title = $1, description = $2, status = $3, updated_at = $4
-		WHERE id = $5 AND user_id = $6
+		SET title = $1, description = $2, status = $3, due_date = $4, completed_at = $5, updated_at = $6
+		WHERE id = $7 AND user_id = $8
 	`
 
 	queryDeleteTask = `
@@ -62,7 +66,9 @@ const (
 
 	queryBulkUpdateStatus = `
 		UPDATE tasks
-		SET status = $1, updated_at = $2
+		SET status = $1,
+			completed_at = CASE WHEN $1 = 'done' THEN COALESCE(completed_at, $2) END,
+			updated_at = $2
 		WHERE id = ANY($3) AND user_id = $4
 	`
 
@@ -77,17 +83,85 @@ const (
 	queryCountTasksByUserIDWithStatus = `
 		SELECT COUNT(*) FROM tasks WHERE user_id = $1 AND status = $2
 	`
+
+	queryTransferAllTasks = `
+		UPDATE tasks
+		SET user_id = $1, updated_at = $2
+		WHERE user_id = $3
+	`
+
+	queryTransferTasks = `
+		UPDATE tasks
+		SET user_id = $1, updated_at = $2
+		WHERE user_id = $3 AND id = ANY($4)
+	`
+
+	queryCountTasksByStatus = `
+		SELECT status, COUNT(*) AS count
+		FROM tasks
+		WHERE user_id = $1
+		GROUP BY status
+	`
+
+	queryTaskStatsSummary = `
+		SELECT
+			COUNT(*) FILTER (WHERE status <> 'done' AND due_date < $2) AS overdue_count,
+			AVG(EXTRACT(EPOCH FROM completed_at - created_at))
+				FILTER (WHERE completed_at >= $3 AND completed_at < $4) AS avg_time_to_done_seconds
+		FROM tasks
+		WHERE user_id = $1
+	`
+
+	queryTaskStatsTimeline = `
+		WITH periods AS (
+			SELECT generate_series(
+				date_trunc($2::text, $3::timestamptz, 'UTC'),
+				$4::timestamptz,
+				('1 ' || $2::text)::interval
+			) AS period_start
+		),
+		created AS (
+			SELECT date_trunc($2::text, created_at, 'UTC') AS period_start, COUNT(*) AS n
+			FROM tasks
+			WHERE user_id = $1 AND created_at >= $3 AND created_at < $4
+			GROUP BY 1
+		),
+		completed AS (
+			SELECT date_trunc($2::text, completed_at, 'UTC') AS period_start, COUNT(*) AS n
+			FROM tasks
+			WHERE user_id = $1 AND completed_at >= $3 AND completed_at < $4
+			GROUP BY 1
+		)
+		SELECT p.period_start, COALESCE(c.n, 0) AS created, COALESCE(d.n, 0) AS completed
+		FROM periods p
+		LEFT JOIN created c ON c.period_start = p.period_start
+		LEFT JOIN completed d ON d.period_start = p.period_start
+		WHERE p.period_start < $4
+		ORDER BY p.period_start
+	`
+)
+
+const (
+	// exportCursorName is the server-side cursor used to stream task exports
+	exportCursorName = "task_export_cursor"
+	// exportFetchSize is the number of rows fetched from the cursor per round-trip
+	exportFetchSize = 500
 )
 
 // Create creates a new task in the database
-func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
-	err := r.db.QueryRowContext(
+func (r *taskRepository) Create(ctx context.Context, task *domain.Task) (err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.Create", "INSERT", "tasks", queryCreateTask)
+	defer func() { tracing.End(span, err) }()
+
+	err = r.db.Writer(ctx).QueryRowxContext(
 		ctx,
 		queryCreateTask,
 		task.UserID,
 		task.Title,
 		task.Description,
 		task.Status,
+		task.DueDate,
+		task.CompletedAt,
 		task.CreatedAt,
 		task.UpdatedAt,
 	).Scan(&task.ID)
@@ -99,14 +173,66 @@ func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
+// CreateBatch inserts tasks using COPY inside a transaction so the batch is all-or-nothing
+func (r *taskRepository) CreateBatch(ctx context.Context, tasks []domain.Task) (_ int64, err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.CreateBatch", "COPY", "tasks", "COPY tasks FROM STDIN")
+	defer func() { tracing.End(span, err) }()
+
+	var inserted int64
+
+	r.db.MarkWrite(ctx)
+	err = database.WithTransaction(ctx, r.db.DB, func(tx *sqlx.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(
+			"tasks",
+			"user_id", "title", "description", "status", "due_date", "completed_at", "created_at", "updated_at",
+		))
+		if err != nil {
+			return fmt.Errorf("failed to prepare copy statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, task := range tasks {
+			if _, err := stmt.ExecContext(
+				ctx,
+				task.UserID,
+				task.Title,
+				task.Description,
+				string(task.Status),
+				task.DueDate,
+				task.CompletedAt,
+				task.CreatedAt,
+				task.UpdatedAt,
+			); err != nil {
+				return fmt.Errorf("failed to copy task row: %w", err)
+			}
+		}
+
+		// Flush buffered rows
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return fmt.Errorf("failed to flush copy: %w", err)
+		}
+
+		inserted = int64(len(tasks))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tasks in batch: %w", err)
+	}
+
+	return inserted, nil
+}
+
 // FindByID finds a task by ID
-func (r *taskRepository) FindByID(ctx context.Context, id string) (*domain.Task, error) {
+func (r *taskRepository) FindByID(ctx context.Context, id string) (_ *domain.Task, err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.FindByID", "SELECT", "tasks", queryFindTaskByID)
+	defer func() { tracing.End(span, err) }()
+
 	task := &domain.Task{}
 
-	err := r.db.GetContext(ctx, task, queryFindTaskByID, id)
+	err = sqlx.GetContext(ctx, r.db.Reader(ctx), task, queryFindTaskByID, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("task not found with id: %s", id)
+			return nil, domain.ErrTaskNotFound
 		}
 		return nil, fmt.Errorf("failed to find task by id: %w", err)
 	}
@@ -115,16 +241,17 @@ func (r *taskRepository) FindByID(ctx context.Context, id string) (*domain.Task,
 }
 
 // FindByUserID finds all tasks for a user with filtering and pagination
-func (r *taskRepository) FindByUserID(ctx context.Context, userID string, page, limit int, status string) ([]domain.Task, int64, error) {
+func (r *taskRepository) FindByUserID(ctx context.Context, userID string, page, limit int, status string, sort domain.TaskSort) (_ []domain.Task, _ int64, err error) {
 	offset := (page - 1) * limit
+	orderBy := orderByClause(sort)
 
 	// Build query based on status filter
-	query := queryFindTasksByUserID + fmt.Sprintf(" ORDER BY created_at DESC LIMIT %d OFFSET %d", limit, offset)
+	query := queryFindTasksByUserID + fmt.Sprintf(" %s LIMIT %d OFFSET %d", orderBy, limit, offset)
 	var countQuery string
 	var args []interface{}
 
 	if status != "" {
-		query = queryFindTasksByUserIDWithStatus + fmt.Sprintf(" ORDER BY created_at DESC LIMIT %d OFFSET %d", limit, offset)
+		query = queryFindTasksByUserIDWithStatus + fmt.Sprintf(" %s LIMIT %d OFFSET %d", orderBy, limit, offset)
 		countQuery = queryCountTasksByUserIDWithStatus
 		args = []interface{}{userID, status}
 	} else {
@@ -132,9 +259,12 @@ func (r *taskRepository) FindByUserID(ctx context.Context, userID string, page,
 		args = []interface{}{userID}
 	}
 
+	ctx, span := startQuerySpan(ctx, "TaskRepository.FindByUserID", "SELECT", "tasks", query)
+	defer func() { tracing.End(span, err) }()
+
 	// Get total count
 	var total int64
-	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	err = sqlx.GetContext(ctx, r.db.Reader(ctx), &total, countQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
 	}
@@ -142,9 +272,9 @@ func (r *taskRepository) FindByUserID(ctx context.Context, userID string, page,
 	// Get tasks
 	var tasks []domain.Task
 	if status != "" {
-		err = r.db.SelectContext(ctx, &tasks, query, userID, status)
+		err = sqlx.SelectContext(ctx, r.db.Reader(ctx), &tasks, query, userID, status)
 	} else {
-		err = r.db.SelectContext(ctx, &tasks, query, userID)
+		err = sqlx.SelectContext(ctx, r.db.Reader(ctx), &tasks, query, userID)
 	}
 
 	if err != nil {
@@ -157,14 +287,99 @@ func (r *taskRepository) FindByUserID(ctx context.Context, userID string, page,
 	return tasks, total, nil
 }
 
+// orderByClause builds the ORDER BY clause for a validated sort, falling back to newest first;
+// id is appended as a tie-breaker so pagination is stable
+func orderByClause(sort domain.TaskSort) string {
+	if !sort.IsValid() {
+		sort = domain.DefaultTaskSort
+	}
+
+	direction := "ASC"
+	if sort.Descending() {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s NULLS LAST, id %s", sort.Field(), direction, direction)
+}
+
+// StreamByUserID declares a server-side cursor for the user's tasks and fetches it in batches,
+// so exports never hold the full result set in memory. The cursor runs on a replica when one is
+// healthy, so slow downloads do not hold primary connections.
+func (r *taskRepository) StreamByUserID(ctx context.Context, userID string, filter TaskExportFilter, fn func(*domain.Task) error) (err error) {
+	query := queryFindTasksByUserID
+	args := []interface{}{userID}
+
+	if filter.Status != "" {
+		query = queryFindTasksByUserIDWithStatus
+		args = append(args, filter.Status)
+	}
+	if filter.WithDueDate {
+		query += " AND due_date IS NOT NULL"
+	}
+	query += " " + orderByClause(filter.Sort)
+
+	ctx, span := startQuerySpan(ctx, "TaskRepository.StreamByUserID", "SELECT", "tasks", query)
+	defer func() { tracing.End(span, err) }()
+
+	return database.WithTransaction(ctx, r.db.ReaderDB(ctx), func(tx *sqlx.Tx) error {
+		declare := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", exportCursorName, query)
+		if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+			return fmt.Errorf("failed to declare export cursor: %w", err)
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", exportFetchSize, exportCursorName)
+		for {
+			fetched, err := r.fetchBatch(ctx, tx, fetch, fn)
+			if err != nil {
+				return err
+			}
+			if fetched < exportFetchSize {
+				return nil
+			}
+		}
+	})
+}
+
+// fetchBatch reads one batch from the export cursor and returns the number of rows seen
+func (r *taskRepository) fetchBatch(ctx context.Context, tx *sqlx.Tx, fetch string, fn func(*domain.Task) error) (int, error) {
+	rows, err := tx.QueryxContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch from export cursor: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		var task domain.Task
+		if err := rows.StructScan(&task); err != nil {
+			return fetched, fmt.Errorf("failed to scan task: %w", err)
+		}
+		if err := fn(&task); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("failed to iterate export cursor: %w", err)
+	}
+
+	return fetched, nil
+}
+
 // Update updates an existing task
-func (r *taskRepository) Update(ctx context.Context, task *domain.Task) error {
-	result, err := r.db.ExecContext(
+func (r *taskRepository) Update(ctx context.Context, task *domain.Task) (err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.Update", "UPDATE", "tasks", queryUpdateTask)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.Writer(ctx).ExecContext(
 		ctx,
 		queryUpdateTask,
 		task.Title,
 		task.Description,
 		task.Status,
+		task.DueDate,
+		task.CompletedAt,
 		task.UpdatedAt,
 		task.ID,
 		task.UserID,
@@ -180,15 +395,18 @@ func (r *taskRepository) Update(ctx context.Context, task *domain.Task) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("task not found or not owned by user")
+		return domain.ErrTaskNotFound
 	}
 
 	return nil
 }
 
 // Delete deletes a task (owned by user)
-func (r *taskRepository) Delete(ctx context.Context, id string, userID string) error {
-	result, err := r.db.ExecContext(ctx, queryDeleteTask, id, userID)
+func (r *taskRepository) Delete(ctx context.Context, id string, userID string) (err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.Delete", "DELETE", "tasks", queryDeleteTask)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteTask, id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
@@ -199,20 +417,23 @@ func (r *taskRepository) Delete(ctx context.Context, id string, userID string) e
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("task not found or not owned by user")
+		return domain.ErrTaskNotFound
 	}
 
 	return nil
 }
 
 // BulkUpdateStatus updates the status of multiple tasks
-func (r *taskRepository) BulkUpdateStatus(ctx context.Context, taskIDs []string, userID string, status domain.TaskStatus) error {
-	result, err := r.db.ExecContext(
+func (r *taskRepository) BulkUpdateStatus(ctx context.Context, taskIDs []string, userID string, status domain.TaskStatus) (err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.BulkUpdateStatus", "UPDATE", "tasks", queryBulkUpdateStatus)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.Writer(ctx).ExecContext(
 		ctx,
 		queryBulkUpdateStatus,
 		status,
-		// Current timestamp for updated_at
-		"NOW()",
+		// Current timestamp for updated_at and completed_at
+		time.Now(),
 		pq.Array(taskIDs),
 		userID,
 	)
@@ -227,17 +448,87 @@ func (r *taskRepository) BulkUpdateStatus(ctx context.Context, taskIDs []string,
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("no tasks updated")
+		return domain.ErrTaskNotFound
 	}
 
 	return nil
 }
 
+// GetStats aggregates a user's task metrics in SQL; interval must be "day" or "week"
+func (r *taskRepository) GetStats(ctx context.Context, userID string, from, to time.Time, interval string) (_ *domain.TaskStats, err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.GetStats", "SELECT", "tasks", queryTaskStatsSummary)
+	defer func() { tracing.End(span, err) }()
+
+	stats := &domain.TaskStats{
+		StatusCounts: make(map[domain.TaskStatus]int64),
+	}
+
+	var counts []struct {
+		Status domain.TaskStatus `db:"status"`
+		Count  int64             `db:"count"`
+	}
+	if err = sqlx.SelectContext(ctx, r.db.Reader(ctx), &counts, queryCountTasksByStatus, userID); err != nil {
+		return nil, fmt.Errorf("failed to count tasks by status: %w", err)
+	}
+	for _, c := range counts {
+		stats.StatusCounts[c.Status] = c.Count
+	}
+
+	var summary struct {
+		OverdueCount         int64           `db:"overdue_count"`
+		AvgTimeToDoneSeconds sql.NullFloat64 `db:"avg_time_to_done_seconds"`
+	}
+	if err = sqlx.GetContext(ctx, r.db.Reader(ctx), &summary, queryTaskStatsSummary, userID, time.Now(), from, to); err != nil {
+		return nil, fmt.Errorf("failed to compute task summary: %w", err)
+	}
+	stats.OverdueCount = summary.OverdueCount
+	if summary.AvgTimeToDoneSeconds.Valid {
+		stats.AvgTimeToDoneSeconds = &summary.AvgTimeToDoneSeconds.Float64
+	}
+
+	if err = sqlx.SelectContext(ctx, r.db.Reader(ctx), &stats.Timeline, queryTaskStatsTimeline, userID, interval, from, to); err != nil {
+		return nil, fmt.Errorf("failed to compute task timeline: %w", err)
+	}
+
+	return stats, nil
+}
+
+// TransferOwnership reassigns tasks owned by fromUserID to toUserID and returns the number moved
+func (r *taskRepository) TransferOwnership(ctx context.Context, fromUserID, toUserID string, taskIDs []string) (_ int64, err error) {
+	query := queryTransferTasks
+	if len(taskIDs) == 0 {
+		query = queryTransferAllTasks
+	}
+
+	ctx, span := startQuerySpan(ctx, "TaskRepository.TransferOwnership", "UPDATE", "tasks", query)
+	defer func() { tracing.End(span, err) }()
+
+	var result sql.Result
+	if len(taskIDs) == 0 {
+		result, err = r.db.Writer(ctx).ExecContext(ctx, queryTransferAllTasks, toUserID, time.Now(), fromUserID)
+	} else {
+		result, err = r.db.Writer(ctx).ExecContext(ctx, queryTransferTasks, toUserID, time.Now(), fromUserID, pq.Array(taskIDs))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer tasks: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
 // ExistsByID checks if a task exists and belongs to the user
-func (r *taskRepository) ExistsByID(ctx context.Context, id string, userID string) (bool, error) {
+func (r *taskRepository) ExistsByID(ctx context.Context, id string, userID string) (_ bool, err error) {
+	ctx, span := startQuerySpan(ctx, "TaskRepository.ExistsByID", "SELECT", "tasks", queryTaskExists)
+	defer func() { tracing.End(span, err) }()
+
 	var exists bool
 
-	err := r.db.GetContext(ctx, &exists, queryTaskExists, id, userID)
+	err = sqlx.GetContext(ctx, database.Conn(ctx, r.db), &exists, queryTaskExists, id, userID)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if task exists: %w", err)
 	}
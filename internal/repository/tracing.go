@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/vedologic/task-manager/internal/repository"
+
+// startQuerySpan starts a client span for a database call. The query text is recorded without
+// arguments, so bound values never end up in traces.
+func startQuerySpan(ctx context.Context, name, operation, table, query string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+			semconv.DBQueryText(query),
+		),
+	)
+}
@@ -5,18 +5,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
 )
 
-// userRepository implements UserRepository interface using raw SQL
+// userRepository implements UserRepository interface using raw SQL. FindByID and List may be
+// served by a read replica; everything else uses the primary.
 type userRepository struct {
-	db *sqlx.DB
+	db *database.Router
 }
 
 // NewUserRepository creates a new user repository instance
-func NewUserRepository(db *sqlx.DB) UserRepository {
+func NewUserRepository(db *database.Router) UserRepository {
 	return &userRepository{
 		db: db,
 	}
@@ -25,19 +28,41 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 // SQL Queries
 const (
 	queryCreateUser = `
-		INSERT INTO users (email, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (email, password_hash, role, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	queryFindUserByIdentity = `
+		SELECT u.id, u.email, u.password_hash, u.role, u.display_name, u.timezone, u.locale,
+		       u.disabled_at, u.email_verified_at, u.pending_email, u.deletion_scheduled_at, u.session_version,
+		       u.totp_secret, u.totp_enabled_at, u.totp_last_used_step, u.created_at, u.updated_at
+		FROM users u
+		JOIN user_identities i ON i.user_id = u.id
+		WHERE i.issuer = $1 AND i.subject = $2
+	`
+
+	queryUpsertUserIdentity = `
+		INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (issuer, subject) DO UPDATE
+		SET email = EXCLUDED.email, last_login_at = EXCLUDED.last_login_at
+		WHERE user_identities.user_id = EXCLUDED.user_id
 		RETURNING id
 	`
 
 	queryFindUserByEmail = `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT id, email, password_hash, role, display_name, timezone, locale,
+		       disabled_at, email_verified_at, pending_email, deletion_scheduled_at, session_version,
+		       totp_secret, totp_enabled_at, totp_last_used_step, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 
 	queryFindUserByID = `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT id, email, password_hash, role, display_name, timezone, locale,
+		       disabled_at, email_verified_at, pending_email, deletion_scheduled_at, session_version,
+		       totp_secret, totp_enabled_at, totp_last_used_step, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -45,34 +70,215 @@ const (
 	queryUserExists = `
 		SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)
 	`
+
+	queryListUsers = `
+		SELECT id, email, password_hash, role, display_name, timezone, locale,
+		       disabled_at, email_verified_at, pending_email, deletion_scheduled_at, session_version,
+		       totp_secret, totp_enabled_at, totp_last_used_step, created_at, updated_at
+		FROM users
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	queryCountUsers = `
+		SELECT COUNT(*) FROM users
+	`
+
+	queryUpdateUserRole = `
+		UPDATE users
+		SET role = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	queryUpdateUserDisabledAt = `
+		UPDATE users
+		SET disabled_at = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	queryMarkUserEmailVerified = `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1
+		WHERE id = $2
+	`
+
+	queryUpdateUserTOTPSecret = `
+		UPDATE users
+		SET totp_secret = $1, totp_enabled_at = NULL, totp_last_used_step = NULL, updated_at = $2
+		WHERE id = $3
+	`
+
+	queryEnableUserTOTP = `
+		UPDATE users
+		SET totp_enabled_at = $1, updated_at = $1
+		WHERE id = $2 AND totp_secret IS NOT NULL
+	`
+
+	// Only a later step may be recorded, so a code cannot be replayed within its validity window
+	queryUseUserTOTPStep = `
+		UPDATE users
+		SET totp_last_used_step = $1
+		WHERE id = $2 AND (totp_last_used_step IS NULL OR totp_last_used_step < $1)
+	`
+
+	queryUpdateUserPassword = `
+		UPDATE users
+		SET password_hash = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	queryUpdateUserPasswordAndRevokeSessions = `
+		UPDATE users
+		SET password_hash = $1, session_version = session_version + 1, updated_at = $2
+		WHERE id = $3
+		RETURNING session_version
+	`
+
+	queryUpdateUserProfile = `
+		UPDATE users
+		SET display_name = $1, timezone = $2, locale = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	queryUpdateUserPendingEmail = `
+		UPDATE users
+		SET pending_email = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	// The pending address must still match, so a superseded confirmation link cannot be applied
+	queryApplyUserEmailChange = `
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verified_at = $1, updated_at = $1
+		WHERE id = $2 AND pending_email = $3
+	`
+
+	queryScheduleUserDeletion = `
+		UPDATE users
+		SET deletion_scheduled_at = $1, session_version = session_version + 1, updated_at = $2
+		WHERE id = $3
+	`
+
+	queryCancelUserDeletion = `
+		UPDATE users
+		SET deletion_scheduled_at = NULL, updated_at = $1
+		WHERE id = $2 AND deletion_scheduled_at IS NOT NULL
+	`
+
+	queryDeleteScheduledUsers = `
+		DELETE FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1
+	`
 )
 
 // Create creates a new user in the database
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
-	err := r.db.QueryRowContext(
+	if user.Role == "" {
+		user.Role = domain.UserRoleUser
+	}
+
+	err := r.db.Writer(ctx).QueryRowxContext(
 		ctx,
 		queryCreateUser,
 		user.Email,
 		user.PasswordHash,
+		user.Role,
+		user.EmailVerifiedAt,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrEmailTaken
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return nil
 }
 
+// FindByIdentity finds the user linked to an external identity, or returns nil if none is linked
+func (r *userRepository) FindByIdentity(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	user := &domain.User{}
+
+	err := sqlx.GetContext(ctx, r.db.Primary(ctx), user, queryFindUserByIdentity, issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user by identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpsertIdentity links an external identity to a user, or records a new login for an existing link.
+// It fails if the identity is already linked to a different user.
+func (r *userRepository) UpsertIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	return upsertIdentity(ctx, r.db.Writer(ctx), identity)
+}
+
+// CreateWithIdentity provisions a new user linked to an external identity in a single transaction
+func (r *userRepository) CreateWithIdentity(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	if user.Role == "" {
+		user.Role = domain.UserRoleUser
+	}
+
+	r.db.MarkWrite(ctx)
+	return database.WithTransaction(ctx, r.db.DB, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			queryCreateUser,
+			user.Email,
+			user.PasswordHash,
+			user.Role,
+			user.EmailVerifiedAt,
+			user.CreatedAt,
+			user.UpdatedAt,
+		).Scan(&user.ID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrEmailTaken
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		identity.UserID = user.ID
+		return upsertIdentity(ctx, tx, identity)
+	})
+}
+
+// upsertIdentity runs queryUpsertUserIdentity on a database or transaction
+func upsertIdentity(ctx context.Context, q sqlx.QueryerContext, identity *domain.UserIdentity) error {
+	err := q.QueryRowxContext(
+		ctx,
+		queryUpsertUserIdentity,
+		identity.UserID,
+		identity.Issuer,
+		identity.Subject,
+		identity.Email,
+		identity.CreatedAt,
+	).Scan(&identity.ID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrIdentityAlreadyLinked
+		}
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return nil
+}
+
 // FindByEmail finds a user by email address
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	user := &domain.User{}
 
-	err := r.db.GetContext(ctx, user, queryFindUserByEmail, email)
+	err := sqlx.GetContext(ctx, r.db.Primary(ctx), user, queryFindUserByEmail, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found with email: %s", email)
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to find user by email: %w", err)
 	}
@@ -84,10 +290,10 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain
 func (r *userRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
 	user := &domain.User{}
 
-	err := r.db.GetContext(ctx, user, queryFindUserByID, id)
+	err := sqlx.GetContext(ctx, r.db.Reader(ctx), user, queryFindUserByID, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found with id: %s", id)
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to find user by id: %w", err)
 	}
@@ -99,10 +305,220 @@ func (r *userRepository) FindByID(ctx context.Context, id string) (*domain.User,
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var exists bool
 
-	err := r.db.GetContext(ctx, &exists, queryUserExists, email)
+	err := sqlx.GetContext(ctx, r.db.Primary(ctx), &exists, queryUserExists, email)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if user exists: %w", err)
 	}
 
 	return exists, nil
 }
+
+// List returns a page of users ordered by ID together with the total count
+func (r *userRepository) List(ctx context.Context, page, limit int) ([]domain.User, int64, error) {
+	var total int64
+	if err := sqlx.GetContext(ctx, r.db.Reader(ctx), &total, queryCountUsers); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	users := []domain.User{}
+	offset := (page - 1) * limit
+	if err := sqlx.SelectContext(ctx, r.db.Reader(ctx), &users, queryListUsers, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// UpdateRole changes the role of a user
+func (r *userRepository) UpdateRole(ctx context.Context, id string, role domain.UserRole) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUpdateUserRole, role, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// SetDisabledAt disables a user at the given time, or re-enables it when disabledAt is nil
+func (r *userRepository) SetDisabledAt(ctx context.Context, id string, disabledAt *time.Time) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUpdateUserDisabledAt, disabledAt, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update user disabled state: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// MarkEmailVerified records that the user verified their email address; an earlier verification time is kept
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryMarkUserEmailVerified, verifiedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUpdateUserPassword, passwordHash, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// SetTOTPSecret stores a pending TOTP secret, or clears two-factor authentication when secret is nil
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id string, secret *string) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUpdateUserTOTPSecret, secret, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update totp secret: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// EnableTOTP activates the pending TOTP secret
+func (r *userRepository) EnableTOTP(ctx context.Context, id string, enabledAt time.Time) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryEnableUserTOTP, enabledAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// UseTOTPStep records a used TOTP time step; it returns false if that step or a later one was already used
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUseUserTOTPStep, step, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UpdatePasswordAndRevokeSessions replaces the password hash and invalidates every issued session.
+// It returns the new session version.
+func (r *userRepository) UpdatePasswordAndRevokeSessions(ctx context.Context, id string, passwordHash string) (int, error) {
+	var sessionVersion int
+
+	err := sqlx.GetContext(ctx, r.db.Writer(ctx), &sessionVersion, queryUpdateUserPasswordAndRevokeSessions, passwordHash, time.Now(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	return sessionVersion, nil
+}
+
+// UpdateProfile saves the display name, timezone and locale of a user
+func (r *userRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	user.UpdatedAt = time.Now()
+
+	result, err := r.db.Writer(ctx).ExecContext(
+		ctx,
+		queryUpdateUserProfile,
+		user.DisplayName,
+		user.Timezone,
+		user.Locale,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// SetPendingEmail records a requested email change, or cancels it when email is nil
+func (r *userRepository) SetPendingEmail(ctx context.Context, id string, email *string) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryUpdateUserPendingEmail, email, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update pending email: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// ApplyEmailChange replaces the email of a user with the pending address, provided it still equals email.
+// It returns false if the pending change was superseded or cancelled.
+func (r *userRepository) ApplyEmailChange(ctx context.Context, id string, email string, verifiedAt time.Time) (bool, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryApplyUserEmailChange, verifiedAt, id, email)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, domain.ErrEmailTaken
+		}
+		return false, fmt.Errorf("failed to change user email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ScheduleDeletion marks the account for deletion at the given time and invalidates every issued session
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id string, deleteAt time.Time) error {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryScheduleUserDeletion, deleteAt, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule user deletion: %w", err)
+	}
+
+	return requireUserAffected(result)
+}
+
+// CancelDeletion clears a scheduled deletion; it returns false if none was scheduled
+func (r *userRepository) CancelDeletion(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryCancelUserDeletion, time.Now(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel user deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteScheduled permanently deletes users whose deletion time has passed and returns how many were removed
+func (r *userRepository) DeleteScheduled(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.Writer(ctx).ExecContext(ctx, queryDeleteScheduledUsers, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduled users: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// requireUserAffected returns an error if an update matched no user
+func requireUserAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
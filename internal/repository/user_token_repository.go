@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/pkg/database"
+)
+
+// userTokenRepository implements UserTokenRepository interface using raw SQL
+type userTokenRepository struct {
+	db *database.Router
+}
+
+// NewUserTokenRepository creates a new user token repository instance
+func NewUserTokenRepository(db *database.Router) UserTokenRepository {
+	return &userTokenRepository{
+		db: db,
+	}
+}
+
+// SQL Queries
+const (
+	queryCreateUserToken = `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	// Marking the token used in the same statement that checks it makes consumption single-use under concurrency
+	queryConsumeUserToken = `
+		UPDATE user_tokens
+		SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING user_id
+	`
+
+	queryInvalidateUserTokens = `
+		UPDATE user_tokens
+		SET used_at = $1
+		WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL
+	`
+)
+
+// Create stores a new token
+func (r *userTokenRepository) Create(ctx context.Context, token *domain.UserToken) error {
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		queryCreateUserToken,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks an unused, unexpired token as used and returns its owner's ID
+func (r *userTokenRepository) Consume(ctx context.Context, tokenHash string, purpose domain.UserTokenPurpose, now time.Time) (int, error) {
+	var userID int
+
+	err := sqlx.GetContext(ctx, r.db.Writer(ctx), &userID, queryConsumeUserToken, now, tokenHash, purpose)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrInvalidEmailToken
+		}
+		return 0, fmt.Errorf("failed to consume user token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// InvalidateForUser marks all unused tokens of a user for the purpose as used
+func (r *userTokenRepository) InvalidateForUser(ctx context.Context, userID int, purpose domain.UserTokenPurpose, now time.Time) error {
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, queryInvalidateUserTokens, now, userID, purpose); err != nil {
+		return fmt.Errorf("failed to invalidate user tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,630 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/vedologic/task-manager/config"
+	"github.com/vedologic/task-manager/internal/handler"
+	"github.com/vedologic/task-manager/internal/metrics"
+	"github.com/vedologic/task-manager/internal/middleware"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/internal/service"
+	"github.com/vedologic/task-manager/migrations"
+	"github.com/vedologic/task-manager/pkg/database"
+	"github.com/vedologic/task-manager/pkg/health"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"github.com/vedologic/task-manager/pkg/mailer"
+	"github.com/vedologic/task-manager/pkg/ratelimit"
+	"github.com/vedologic/task-manager/pkg/tracing"
+	"go.uber.org/zap"
+
+	_ "github.com/vedologic/task-manager/docs"
+)
+
+// idempotencyCleanupInterval is how often expired idempotency keys are deleted
+const idempotencyCleanupInterval = time.Hour
+
+// Run wires up the API, serves it and blocks until ctx is cancelled, then shuts down gracefully.
+// version is reported in traces.
+func Run(ctx context.Context, cfg *config.Config, log *logger.Logger, version string) error {
+	// Log startup information
+	log.Info("Task Manager API starting up")
+
+	// Catch SIGHUP from the start; the configuration is reloaded once the API is running
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	log.Info("Configuration loaded")
+
+	log.Info(fmt.Sprintf("Server configured to run on %s:%s", cfg.Server.Host, cfg.Server.Port))
+	log.Info(fmt.Sprintf("Environment: %s", cfg.Server.Env))
+	log.Info(fmt.Sprintf("Log Level: %s", cfg.Log.Level))
+
+	// Initialize tracing; trace context is propagated even when span export is disabled
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: cfg.Tracing.ServiceName,
+		Version:     version,
+		Environment: cfg.Server.Env,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Protocol:    cfg.Tracing.Protocol,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	if cfg.Tracing.Enabled {
+		log.Info(fmt.Sprintf("Tracing: exporting to %s over %s (sample ratio %.2f)", cfg.Tracing.Endpoint, cfg.Tracing.Protocol, cfg.Tracing.SampleRatio))
+	} else {
+		log.Info("Tracing: span export disabled")
+	}
+
+	if cfg.JWT.UsesDefaultSecret() {
+		log.Warn("JWT_SECRET is the built-in development default; set a secret before exposing the API")
+	}
+	if cfg.Auth.UsesDefaultTOTPEncryptionKey() {
+		log.Warn("AUTH_TOTP_ENCRYPTION_KEY is the built-in development default; set a key before exposing the API")
+	}
+
+	gin.SetMode(ginMode(cfg.Server.Env))
+
+	// Serve the health checks while the API starts, so orchestrators see the instance as alive but
+	// not ready while it waits for the database; the API routes replace them once wired up
+	healthChecks := health.New(cfg.Health.CheckTimeout)
+	healthChecks.SetStarting()
+	healthHandler := handler.NewHealthHandler(healthChecks, log.Logger)
+
+	startupRouter := gin.New()
+	handler.SetupStartupRoutes(startupRouter, healthHandler, log.Logger)
+	routes := &routerSwitch{}
+	routes.router.Store(startupRouter)
+
+	// Setup HTTP server
+	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      routes,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Bind before starting up, so a port conflict fails immediately
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	defer srv.Close()
+
+	log.Info(fmt.Sprintf("Starting HTTP server on %s", addr))
+
+	// Start servers in goroutines; a listener failure stops the process like a shutdown signal
+	serveErr := make(chan error, 2)
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			serveErr <- fmt.Errorf("failed to start server: %w", err)
+		}
+	}()
+
+	// Initialize database connection, waiting for the database to accept connections
+	log.Info("Initializing database connection...")
+	dbConfig := cfg.Database.Connection()
+
+	db, err := database.WaitForPostgresDB(ctx, dbConfig, cfg.Database.Connect, log.Logger)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Info("Shutdown signal received while waiting for the database, exiting")
+			return nil
+		}
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer database.Close(db)
+
+	log.Info("Database connection established successfully")
+	log.Info(fmt.Sprintf("Database: %s:%d/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName))
+	log.Info(fmt.Sprintf("Connection Pool - Max Open: %d, Max Idle: %d", cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns))
+
+	// Open read replicas; their reads fall back to the primary until they pass a health check
+	replicas := make([]*sqlx.DB, 0, len(cfg.Database.ReplicaURLs))
+	for i, replicaURL := range cfg.Database.ReplicaURLs {
+		replica, err := database.NewReplicaDB(replicaURL, dbConfig)
+		if err != nil {
+			for _, opened := range replicas {
+				database.Close(opened)
+			}
+			return fmt.Errorf("failed to initialize read replica %d: %w", i+1, err)
+		}
+		replicas = append(replicas, replica)
+	}
+	// Retry transient failures of reads and transactions; fail fast while the database is unreachable
+	retrier := database.NewRetrier(
+		cfg.Database.Retry,
+		database.NewCircuitBreaker(cfg.Database.BreakerThreshold, cfg.Database.BreakerCooldown),
+		database.RetryHooks{
+			OnRetry: func(operation string, class database.ErrorClass) {
+				metrics.DBRetries.WithLabelValues(operation, string(class)).Inc()
+			},
+			OnRejected: func(operation string) {
+				metrics.DBCircuitBreakerRejections.WithLabelValues(operation).Inc()
+			},
+			OnStateChange: func(state database.BreakerState) {
+				metrics.DBCircuitBreakerState.Set(float64(state))
+			},
+		},
+		log.Logger,
+	)
+
+	dbRouter := database.NewRouter(db, replicas, cfg.Database.ReplicaStickiness, retrier, log.Logger)
+	defer dbRouter.CloseReplicas()
+	if len(replicas) > 0 {
+		dbRouter.CheckReplicas(ctx, cfg.Health.CheckTimeout)
+		log.Info(fmt.Sprintf("Read replicas: %d (reads stay on the primary for %s after a write)", len(replicas), cfg.Database.ReplicaStickiness))
+	}
+
+	migrationManager := database.NewMigrationManager(dbConfig.URL(), migrations.FS, log.Logger)
+
+	if cfg.Database.MigrateOnStartup {
+		// Apply pending migrations; replicas starting together wait on the migration lock
+		log.Info("Executing database migrations...")
+		if err := migrationManager.RunMigrationsIfNeeded(ctx, db, cfg.Database.MigrationLockTimeout); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	} else {
+		// Migrations are applied separately; refuse to run against a schema this binary does not match
+		log.Info("Automatic migrations disabled, checking schema version...")
+		if err := migrationManager.CheckVersion(); err != nil {
+			return fmt.Errorf("schema version check failed: %w", err)
+		}
+	}
+
+	// Verify schema integrity after migrations
+	if err := migrationManager.VerifySchema(db, log.Logger); err != nil {
+		return fmt.Errorf("database schema verification failed: %w", err)
+	}
+
+	log.Info("All migrations completed and schema verified successfully")
+
+	// Expose connection pool and schema version metrics
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterDBStats(db.DB, cfg.Database.DBName); err != nil {
+			return fmt.Errorf("failed to register database metrics: %w", err)
+		}
+		for name, replica := range dbRouter.Replicas() {
+			if err := metrics.RegisterDBStats(replica.DB, cfg.Database.DBName+"/"+name); err != nil {
+				return fmt.Errorf("failed to register read replica metrics: %w", err)
+			}
+		}
+		version, dirty, err := database.ReadMigrationStatus(ctx, db)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Could not read migration version for metrics: %v", err))
+		} else {
+			metrics.SetSchemaVersion(version, dirty)
+		}
+	}
+
+	// Initialize repositories
+	log.Info("Initializing repositories...")
+	userRepo := repository.NewUserRepository(dbRouter)
+	taskRepo := repository.NewTaskRepository(dbRouter)
+	viewRepo := repository.NewSavedViewRepository(dbRouter)
+	auditRepo := repository.NewAuditLogRepository(dbRouter)
+	tokenRepo := repository.NewAccessTokenRepository(dbRouter)
+	userTokenRepo := repository.NewUserTokenRepository(dbRouter)
+	throttleRepo := repository.NewLoginThrottleRepository(dbRouter)
+	recoveryRepo := repository.NewRecoveryCodeRepository(dbRouter)
+	idempotencyRepo := repository.NewIdempotencyKeyRepository(dbRouter)
+	log.Info("Repositories initialized successfully")
+	log.Info("User Repository: ready")
+	log.Info("Task Repository: ready")
+	log.Info("Saved View Repository: ready")
+	log.Info("Audit Log Repository: ready")
+	log.Info("Access Token Repository: ready")
+	log.Info("User Token Repository: ready")
+	log.Info("Login Throttle Repository: ready")
+	log.Info("Recovery Code Repository: ready")
+	log.Info("Idempotency Key Repository: ready")
+
+	// Initialize mailer
+	mail, err := mailer.New(mailer.Config{
+		Driver:   cfg.Mail.Driver,
+		Host:     cfg.Mail.Host,
+		Port:     cfg.Mail.Port,
+		Username: cfg.Mail.Username,
+		Password: cfg.Mail.Password,
+		From:     cfg.Mail.From,
+	}, log.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+	log.Info(fmt.Sprintf("Mailer: %s", cfg.Mail.Driver))
+
+	// Initialize services; units of work spanning several repositories share a transaction
+	log.Info("Initializing services...")
+	txManager := database.NewTxManager(db, retrier)
+	authService, err := service.NewAuthService(userRepo, userTokenRepo, throttleRepo, recoveryRepo, txManager, mail, service.AuthConfig{
+		JWTSecret:             cfg.JWT.Secret,
+		JWTExpiryHours:        cfg.JWT.ExpiryHours,
+		RequireVerifiedEmail:  cfg.Auth.RequireVerifiedEmail,
+		VerificationTokenTTL:  cfg.Auth.VerificationTokenTTL,
+		PasswordResetTokenTTL: cfg.Auth.PasswordResetTokenTTL,
+		PublicURL:             cfg.Server.PublicURL,
+		LoginThrottle: service.LoginThrottleConfig{
+			MaxFailures:     cfg.Auth.LoginMaxFailures,
+			IPMaxFailures:   cfg.Auth.LoginIPMaxFailures,
+			Window:          cfg.Auth.LoginFailureWindow,
+			LockoutDuration: cfg.Auth.LoginLockoutDuration,
+			DelayBase:       cfg.Auth.LoginDelayBase,
+			MaxDelay:        cfg.Auth.LoginMaxDelay,
+		},
+		TOTPIssuer:            cfg.Auth.TOTPIssuer,
+		TOTPEncryptionKey:     cfg.Auth.TOTPEncryptionKey,
+		TwoFactorChallengeTTL: cfg.Auth.TwoFactorChallengeTTL,
+	}, log.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize auth service: %w", err)
+	}
+	taskService := service.NewTaskService(taskRepo, txManager)
+	viewService := service.NewSavedViewService(viewRepo)
+	adminService := service.NewAdminService(userRepo, taskRepo, auditRepo, txManager, log.Logger)
+	tokenService := service.NewAccessTokenService(tokenRepo, userRepo)
+	userService := service.NewUserService(userRepo, userTokenRepo, txManager, mail, service.UserConfig{
+		JWTSecret:           cfg.JWT.Secret,
+		JWTExpiryHours:      cfg.JWT.ExpiryHours,
+		PublicURL:           cfg.Server.PublicURL,
+		EmailChangeTokenTTL: cfg.Auth.EmailChangeTokenTTL,
+		ReauthWindow:        cfg.Auth.ReauthWindow,
+		DeletionGracePeriod: cfg.Auth.AccountDeletionGrace,
+	}, log.Logger)
+
+	var oidcService service.OIDCService
+	if cfg.OIDC.Enabled() {
+		oidcService = service.NewOIDCService(service.OIDCConfig{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			StateKey:     cfg.JWT.Secret,
+			StateTTL:     cfg.OIDC.StateTTL,
+		}, authService)
+	}
+	log.Info("Services initialized successfully")
+	log.Info("Auth Service: ready")
+	log.Info("Task Service: ready")
+	log.Info("Saved View Service: ready")
+	log.Info("Admin Service: ready")
+	log.Info("Access Token Service: ready")
+	log.Info("User Service: ready")
+	if oidcService != nil {
+		log.Info(fmt.Sprintf("OIDC Service: ready (issuer %s)", cfg.OIDC.IssuerURL))
+	}
+
+	// Initialize handlers
+	log.Info("Initializing handlers...")
+	authHandler := handler.NewAuthHandler(authService, log.Logger)
+	taskHandler := handler.NewTaskHandler(taskService, viewService, log.Logger)
+	viewHandler := handler.NewSavedViewHandler(viewService, log.Logger)
+	adminHandler := handler.NewAdminHandler(adminService, log.Logger)
+	tokenHandler := handler.NewAccessTokenHandler(tokenService, log.Logger)
+	userHandler := handler.NewUserHandler(userService, log.Logger)
+
+	// Readiness depends on the database and a clean schema; background jobs add liveness checks below
+	healthChecks.AddReadinessCheck("database", health.DatabaseCheck(db))
+	healthChecks.AddReadinessCheck("migrations", health.MigrationCheck(func(ctx context.Context) (uint, bool, error) {
+		return database.ReadMigrationStatus(ctx, db)
+	}))
+
+	var oidcHandler *handler.OIDCHandler
+	if oidcService != nil {
+		oidcHandler = handler.NewOIDCHandler(oidcService, cfg.OIDC.RedirectURL, int(cfg.OIDC.StateTTL.Seconds()), log.Logger)
+	}
+	log.Info("Handlers initialized successfully")
+	log.Info("Auth Handler: ready")
+	log.Info("Task Handler: ready")
+	log.Info("Saved View Handler: ready")
+	log.Info("Admin Handler: ready")
+	log.Info("Access Token Handler: ready")
+	log.Info("User Handler: ready")
+	log.Info("Health Handler: ready")
+	if oidcHandler != nil {
+		log.Info("OIDC Handler: ready")
+	}
+
+	// Initialize rate limiting
+	rateLimits := rateLimitGroups(cfg.RateLimit)
+
+	var rateLimiter *middleware.RateLimiter
+	var rateLimitRepo repository.RateLimitRepository
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store
+		if cfg.RateLimit.Store == ratelimit.StorePostgres {
+			rateLimitRepo = repository.NewRateLimitRepository(dbRouter)
+			store = rateLimitRepo
+		} else {
+			store = ratelimit.NewMemoryStore()
+		}
+		rateLimiter = middleware.NewRateLimiter(store, rateLimits, log.Logger)
+		log.Info(fmt.Sprintf("Rate Limiter: ready (%s store)", cfg.RateLimit.Store))
+	} else {
+		log.Warn("Rate limiting is disabled")
+	}
+
+	idempotency := middleware.Idempotency(idempotencyRepo, cfg.Idempotency.KeyTTL, log.Logger)
+
+	// Setup router and routes
+	log.Info("Setting up routes and middleware...")
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	// Metrics share the API port unless an admin port is configured
+	serveMetrics := cfg.Metrics.Enabled && cfg.Metrics.Port == ""
+	handler.SetupRoutes(router, authHandler, taskHandler, viewHandler, adminHandler, tokenHandler, userHandler, oidcHandler, healthHandler, cfg.JWT.Secret, authService, tokenService, rateLimiter, idempotency, cfg.Tracing.ServiceName, serveMetrics, log.Logger)
+	log.Info("Routes and middleware configured successfully")
+
+	// Serve metrics on the admin port, if configured
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled && cfg.Metrics.Port != "" {
+		mux := http.NewServeMux()
+		mux.Handle(metrics.Path, metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Metrics.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+
+		log.Info(fmt.Sprintf("Starting metrics server on %s", metricsSrv.Addr))
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("failed to start metrics server: %w", err)
+			}
+		}()
+	}
+
+	// Background maintenance runs until shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Permanently delete accounts whose deletion grace period has ended
+	startJob(jobsCtx, healthChecks, "purge deleted accounts", cfg.Auth.AccountPurgeInterval, func(ctx context.Context) error {
+		_, err := userService.PurgeDeletedAccounts(ctx)
+		return err
+	}, log.Logger)
+
+	// Drop idempotency keys whose replay window has ended
+	startJob(jobsCtx, healthChecks, "delete expired idempotency keys", idempotencyCleanupInterval, func(ctx context.Context) error {
+		_, err := idempotencyRepo.DeleteExpired(ctx, time.Now())
+		return err
+	}, log.Logger)
+
+	// Move reads off replicas that stop answering, and back once they recover
+	if len(replicas) > 0 {
+		startJob(jobsCtx, healthChecks, "check read replicas", cfg.Database.ReplicaCheckInterval, func(ctx context.Context) error {
+			dbRouter.CheckReplicas(ctx, cfg.Health.CheckTimeout)
+			return nil
+		}, log.Logger)
+	}
+
+	// Reconnect with database credentials rotated in the secret provider
+	if cfg.Secrets.Enabled() {
+		startJob(jobsCtx, healthChecks, "refresh secrets", cfg.Secrets.RefreshInterval, func(ctx context.Context) error {
+			return refreshDBCredentials(ctx, db, &dbConfig, migrationManager, log.Logger)
+		}, log.Logger)
+	}
+
+	// Drop idle shared rate limit buckets; a bucket unused for its longest period has refilled
+	if rateLimitRepo != nil {
+		idle := longestRatePeriod(rateLimits)
+		startJob(jobsCtx, healthChecks, "prune rate limit buckets", idle, func(ctx context.Context) error {
+			_, err := rateLimitRepo.DeleteIdle(ctx, time.Now().Add(-idle))
+			return err
+		}, log.Logger)
+	}
+
+	// Replace the startup routes with the API and start running the readiness checks
+	routes.router.Store(router)
+	healthChecks.SetStarted()
+
+	go reloadOnSignal(jobsCtx, reload, log, rateLimiter)
+
+	log.Info("Task Manager API is ready to serve requests on http://" + addr)
+	log.Info("Swagger UI available at http://" + addr + "/swagger/index.html")
+	log.Info("Health checks available at http://" + addr + "/livez and http://" + addr + "/readyz")
+	if serveMetrics {
+		log.Info("Metrics available at http://" + addr + metrics.Path)
+	} else if metricsSrv != nil {
+		log.Info("Metrics available at http://" + metricsSrv.Addr + metrics.Path)
+	}
+
+	// Handle graceful shutdown
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Info("Shutdown signal received, shutting down gracefully...")
+	case runErr = <-serveErr:
+		log.Error("Server failed, shutting down", zap.Error(runErr))
+	}
+
+	// Fail readiness first and give load balancers time to stop routing new requests here
+	healthChecks.SetShuttingDown()
+	if runErr == nil && cfg.Server.ShutdownDrainDelay > 0 {
+		log.Info(fmt.Sprintf("Readiness failing, draining for %s", cfg.Server.ShutdownDrainDelay))
+		time.Sleep(cfg.Server.ShutdownDrainDelay)
+	}
+
+	stopJobs()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error(fmt.Sprintf("Error during graceful shutdown: %v", err))
+	}
+
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			log.Error(fmt.Sprintf("Error shutting down metrics server: %v", err))
+		}
+	}
+
+	// Flush spans of the requests that just finished
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error(fmt.Sprintf("Error flushing traces: %v", err))
+	}
+
+	log.Info("Task Manager API shut down successfully")
+	return runErr
+}
+
+// ginMode maps the environment to a gin mode
+func ginMode(env string) string {
+	switch env {
+	case config.EnvProduction:
+		return gin.ReleaseMode
+	case config.EnvTest:
+		return gin.TestMode
+	default:
+		return gin.DebugMode
+	}
+}
+
+// rateLimitGroups maps the route groups to their configured limits
+func rateLimitGroups(cfg config.RateLimitConfig) map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		middleware.RateLimitGroupAuth:    cfg.Auth,
+		middleware.RateLimitGroupAccount: cfg.Account,
+		middleware.RateLimitGroupTasks:   cfg.Tasks,
+		middleware.RateLimitGroupViews:   cfg.Views,
+		middleware.RateLimitGroupAdmin:   cfg.Admin,
+	}
+}
+
+// reloadOnSignal reloads the configuration on every signal and applies the settings that are safe
+// to change while running: the log level and the rate limits. Other settings need a restart. An
+// invalid configuration is reported and the current settings are kept.
+func reloadOnSignal(ctx context.Context, signals <-chan os.Signal, log *logger.Logger, rateLimiter *middleware.RateLimiter) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Error("Configuration reload failed, keeping the current settings", zap.Error(err))
+			continue
+		}
+
+		if err := log.SetLevel(cfg.Log.Level); err != nil {
+			log.Error("Failed to apply the reloaded log level", zap.Error(err))
+		}
+
+		if rateLimiter != nil {
+			limits := rateLimitGroups(cfg.RateLimit)
+			if !cfg.RateLimit.Enabled {
+				limits = nil
+			}
+			rateLimiter.SetLimits(limits)
+		} else if cfg.RateLimit.Enabled {
+			log.Warn("Rate limiting was disabled at startup; enabling it requires a restart")
+		}
+
+		log.Info("Configuration reloaded",
+			zap.String("log_level", cfg.Log.Level),
+			zap.Bool("rate_limit_enabled", cfg.RateLimit.Enabled && rateLimiter != nil),
+		)
+	}
+}
+
+// refreshDBCredentials reloads the configuration, which reads the secret provider again, and switches
+// the pool and the migration manager to new database credentials. current holds the credentials in
+// use and is updated once the new ones are applied.
+func refreshDBCredentials(ctx context.Context, db *sqlx.DB, current *database.Config, migrationManager *database.MigrationManager, log *zap.Logger) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	next := cfg.Database.Connection()
+	if next.User == current.User && next.Password == current.Password {
+		return nil
+	}
+
+	if err := database.UpdateCredentials(ctx, db, next.User, next.Password); err != nil {
+		return fmt.Errorf("failed to apply rotated database credentials: %w", err)
+	}
+	current.User, current.Password = next.User, next.Password
+	migrationManager.SetURL(current.URL())
+
+	log.Info("Database credentials rotated, reconnecting the pool", zap.String("user", next.User))
+	return nil
+}
+
+// routerSwitch serves the router stored last, so the API routes can replace the startup routes
+// while the server is listening
+type routerSwitch struct {
+	router atomic.Pointer[gin.Engine]
+}
+
+// ServeHTTP serves the request with the current router
+func (s *routerSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.Load().ServeHTTP(w, r)
+}
+
+// startJob runs fn in the background every interval and registers a liveness check that fails
+// when the job has not completed a run for two intervals
+func startJob(ctx context.Context, checks *health.Health, name string, interval time.Duration, fn func(ctx context.Context) error, log *zap.Logger) {
+	if interval <= 0 {
+		log.Warn("Background job disabled: interval is not positive", zap.String("job", name))
+		return
+	}
+
+	heartbeat := health.NewHeartbeat(2 * interval)
+	checks.AddLivenessCheck("job: "+name, heartbeat)
+
+	go runPeriodically(ctx, name, interval, fn, heartbeat, log)
+}
+
+// runPeriodically runs fn immediately and then on every interval until ctx is cancelled
+func runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error, heartbeat *health.Heartbeat, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Background job failed", zap.String("job", name), zap.Error(err))
+		}
+		heartbeat.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// longestRatePeriod returns the longest refill period among the enabled limits
+func longestRatePeriod(limits map[string]ratelimit.Limit) time.Duration {
+	var longest time.Duration
+	for _, limit := range limits {
+		if limit.Enabled() && limit.Per > longest {
+			longest = limit.Per
+		}
+	}
+	return longest
+}
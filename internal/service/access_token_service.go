@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/utils"
+)
+
+const (
+	// accessTokenBytes is the amount of randomness in a personal access token
+	accessTokenBytes = 32
+	// accessTokenDisplayLength is the number of leading characters kept for identifying a token
+	accessTokenDisplayLength = 12
+)
+
+// accessTokenService implements AccessTokenService interface with business logic
+type accessTokenService struct {
+	tokenRepo repository.AccessTokenRepository
+	userRepo  repository.UserRepository
+}
+
+// NewAccessTokenService creates a new personal access token service
+func NewAccessTokenService(tokenRepo repository.AccessTokenRepository, userRepo repository.UserRepository) AccessTokenService {
+	return &accessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Create issues a new token; the plaintext value is returned only in this response
+func (s *accessTokenService) Create(ctx context.Context, userID string, req dto.CreateAccessTokenRequest) (*dto.CreateAccessTokenResponse, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !domain.TokenScope(scope).IsValid() {
+			return nil, domain.InvalidInput(fmt.Sprintf("invalid scope: %s", scope))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	// Convert userID string to int
+	userIDInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	raw, err := utils.GenerateSecureToken(domain.AccessTokenPrefix, accessTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	token := &domain.PersonalAccessToken{
+		UserID:      userIDInt,
+		Name:        req.Name,
+		TokenPrefix: raw[:accessTokenDisplayLength],
+		TokenHash:   utils.HashToken(raw),
+		Scopes:      strings.Join(scopes, " "),
+		CreatedAt:   now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return &dto.CreateAccessTokenResponse{
+		Token:               raw,
+		AccessTokenResponse: toAccessTokenResponse(token),
+	}, nil
+}
+
+// List returns the user's active and expired (but not revoked) tokens
+func (s *accessTokenService) List(ctx context.Context, userID string) (*dto.AccessTokenListResponse, error) {
+	tokens, err := s.tokenRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+
+	tokenResponses := make([]dto.AccessTokenResponse, len(tokens))
+	for i := range tokens {
+		tokenResponses[i] = toAccessTokenResponse(&tokens[i])
+	}
+
+	return &dto.AccessTokenListResponse{Tokens: tokenResponses}, nil
+}
+
+// Revoke revokes one of the user's tokens
+func (s *accessTokenService) Revoke(ctx context.Context, tokenID string, userID string) error {
+	if err := s.tokenRepo.Revoke(ctx, tokenID, userID); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate resolves a plaintext token to its owner, rejecting revoked and expired tokens
+// and tokens of disabled accounts or accounts scheduled for deletion, and records its use
+func (s *accessTokenService) Authenticate(ctx context.Context, raw string) (*domain.User, *domain.PersonalAccessToken, error) {
+	token, err := s.tokenRepo.FindByHash(ctx, utils.HashToken(raw))
+	if err != nil {
+		return nil, nil, domain.ErrInvalidAccessToken
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, nil, domain.ErrInvalidAccessToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, strconv.Itoa(token.UserID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find token owner: %w", err)
+	}
+	if user.IsDisabled() {
+		return nil, nil, domain.ErrAccountDisabled
+	}
+	if user.IsDeletionScheduled() {
+		return nil, nil, domain.ErrAccountDisabled.WithMessage("Account is scheduled for deletion")
+	}
+
+	// Usage tracking is best effort and must not block authentication
+	_ = s.tokenRepo.TouchLastUsed(ctx, token.ID, now)
+
+	return user, token, nil
+}
+
+// toAccessTokenResponse converts a token to its public representation
+func toAccessTokenResponse(token *domain.PersonalAccessToken) dto.AccessTokenResponse {
+	resp := dto.AccessTokenResponse{
+		ID:        fmt.Sprintf("%d", token.ID),
+		Name:      token.Name,
+		Prefix:    token.TokenPrefix,
+		Scopes:    token.ScopeList(),
+		CreatedAt: token.CreatedAt.String(),
+	}
+	if token.ExpiresAt != nil {
+		resp.ExpiresAt = token.ExpiresAt.String()
+	}
+	if token.LastUsedAt != nil {
+		resp.LastUsedAt = token.LastUsedAt.String()
+	}
+	return resp
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/mailer"
+	"github.com/vedologic/task-manager/pkg/utils"
+)
+
+// userTokenBytes is the amount of randomness in emailed single-use tokens
+const userTokenBytes = 32
+
+// mailSendTimeout bounds delivery of a single transactional email
+const mailSendTimeout = 15 * time.Second
+
+// backgroundMailTimeout bounds the lookup, token and delivery of an email sent after the response
+const backgroundMailTimeout = 30 * time.Second
+
+// accountMailer issues single-use tokens and sends the account emails that carry them
+type accountMailer struct {
+	tokenRepo repository.UserTokenRepository
+	mailer    mailer.Mailer
+	publicURL string
+}
+
+// newAccountMailer creates an account mailer; links in emails point at publicURL
+func newAccountMailer(tokenRepo repository.UserTokenRepository, mailer mailer.Mailer, publicURL string) *accountMailer {
+	return &accountMailer{
+		tokenRepo: tokenRepo,
+		mailer:    mailer,
+		publicURL: publicURL,
+	}
+}
+
+// createToken stores the hash of a new single-use token and returns the plaintext value
+func (m *accountMailer) createToken(ctx context.Context, user *domain.User, purpose domain.UserTokenPurpose, ttl time.Duration) (string, error) {
+	raw, err := utils.GenerateSecureToken("", userTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	token := &domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := m.tokenRepo.Create(ctx, token); err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+// send delivers a message with a bounded timeout, independent of request cancellation
+func (m *accountMailer) send(ctx context.Context, msg mailer.Message) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+	defer cancel()
+
+	if err := m.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// link builds an absolute link to a page of the application carrying a token
+func (m *accountMailer) link(path, token string) string {
+	return strings.TrimRight(m.publicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"github.com/vedologic/task-manager/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Audit log actions recorded by the admin service
+const (
+	AuditActionListUsers      = "admin.users.list"
+	AuditActionCreateUser     = "admin.user.create"
+	AuditActionResetPassword  = "admin.user.reset_password"
+	AuditActionUpdateUserRole = "admin.user.update_role"
+	AuditActionDisableUser    = "admin.user.disable"
+	AuditActionEnableUser     = "admin.user.enable"
+	AuditActionListUserTasks  = "admin.user.tasks.list"
+	AuditActionTransferTasks  = "admin.user.tasks.transfer"
+	AuditActionListAuditLogs  = "admin.audit_logs.list"
+)
+
+// adminService implements AdminService interface with business logic
+type adminService struct {
+	userRepo  repository.UserRepository
+	taskRepo  repository.TaskRepository
+	auditRepo repository.AuditLogRepository
+	tx        TxManager
+	log       *zap.Logger
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	auditRepo repository.AuditLogRepository,
+	tx TxManager,
+	log *zap.Logger,
+) AdminService {
+	return &adminService{
+		userRepo:  userRepo,
+		taskRepo:  taskRepo,
+		auditRepo: auditRepo,
+		tx:        tx,
+		log:       log,
+	}
+}
+
+// normalizePage applies the same pagination bounds as task listing
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+// totalPages calculates the number of pages for a total count
+func totalPages(total int64, limit int) int {
+	return int((total + int64(limit) - 1) / int64(limit))
+}
+
+// audit records an admin action in the audit log and the application log.
+// Changes are written in the same transaction as their audit entry, so no admin action goes unrecorded.
+func (s *adminService) audit(ctx context.Context, actorID, action, targetType, targetID string, details map[string]interface{}) error {
+	entry := &domain.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		CreatedAt:  time.Now(),
+	}
+
+	if actor, err := strconv.Atoi(actorID); err == nil {
+		entry.ActorUserID = &actor
+	}
+
+	if details != nil {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit details: %w", err)
+		}
+		entry.Details = string(encoded)
+	}
+
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	logger.WithContext(ctx, s.log).Info("Admin action",
+		zap.String("actor_user_id", actorID),
+		zap.String("action", action),
+		zap.String("target_type", targetType),
+		zap.String("target_id", targetID),
+		zap.Any("details", details),
+	)
+
+	return nil
+}
+
+// ListUsers returns a page of all users
+func (s *adminService) ListUsers(ctx context.Context, actorID string, page, limit int) (*dto.AdminUserListResponse, error) {
+	page, limit = normalizePage(page, limit)
+
+	if err := s.audit(ctx, actorID, AuditActionListUsers, "user", "", map[string]interface{}{"page": page, "limit": limit}); err != nil {
+		return nil, err
+	}
+
+	users, total, err := s.userRepo.List(ctx, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	userResponses := make([]dto.AdminUserResponse, len(users))
+	for i := range users {
+		userResponses[i] = toAdminUserResponse(&users[i])
+	}
+
+	return &dto.AdminUserListResponse{
+		Users:      userResponses,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages(total, limit),
+	}, nil
+}
+
+// CreateUser creates a user whose email address is already verified, for accounts set up by operators
+func (s *adminService) CreateUser(ctx context.Context, actorID string, req dto.CreateUserRequest) (*dto.AdminUserResponse, error) {
+	if !req.Role.IsValid() {
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid role: %s", req.Role))
+	}
+
+	passwordHash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		Email:           req.Email,
+		PasswordHash:    passwordHash,
+		Role:            req.Role,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return s.audit(ctx, actorID, AuditActionCreateUser, "user", strconv.Itoa(user.ID), map[string]interface{}{"role": user.Role})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toAdminUserResponse(user)
+	return &resp, nil
+}
+
+// ResetUserPassword sets a new password for a user and revokes their existing sessions
+func (s *adminService) ResetUserPassword(ctx context.Context, actorID, userID string, req dto.AdminResetPasswordRequest) error {
+	passwordHash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.UpdatePasswordAndRevokeSessions(ctx, userID, passwordHash); err != nil {
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+		return s.audit(ctx, actorID, AuditActionResetPassword, "user", userID, nil)
+	})
+}
+
+// UpdateUserRole changes a user's role; admins cannot change their own role
+func (s *adminService) UpdateUserRole(ctx context.Context, actorID, userID string, role domain.UserRole) (*dto.AdminUserResponse, error) {
+	if !role.IsValid() {
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid role: %s", role))
+	}
+
+	if actorID == userID {
+		return nil, domain.ErrSelfModification.WithMessage("Administrators cannot change their own role")
+	}
+
+	var user *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to find user: %w", err)
+		}
+
+		if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+
+		return s.audit(ctx, actorID, AuditActionUpdateUserRole, "user", userID, map[string]interface{}{"from": user.Role, "to": role})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	resp := toAdminUserResponse(user)
+	return &resp, nil
+}
+
+// DisableUser blocks a user from logging in and invalidates their existing tokens
+func (s *adminService) DisableUser(ctx context.Context, actorID, userID string) (*dto.AdminUserResponse, error) {
+	if actorID == userID {
+		return nil, domain.ErrSelfModification.WithMessage("Administrators cannot disable their own account")
+	}
+
+	now := time.Now()
+	return s.setDisabledAt(ctx, actorID, userID, &now, AuditActionDisableUser)
+}
+
+// EnableUser re-enables a disabled user
+func (s *adminService) EnableUser(ctx context.Context, actorID, userID string) (*dto.AdminUserResponse, error) {
+	return s.setDisabledAt(ctx, actorID, userID, nil, AuditActionEnableUser)
+}
+
+// setDisabledAt updates the disabled state of a user and records the action
+func (s *adminService) setDisabledAt(ctx context.Context, actorID, userID string, disabledAt *time.Time, action string) (*dto.AdminUserResponse, error) {
+	var user *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SetDisabledAt(ctx, userID, disabledAt); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		if err := s.audit(ctx, actorID, action, "user", userID, nil); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to find user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toAdminUserResponse(user)
+	return &resp, nil
+}
+
+// ListUserTasks returns a page of any user's tasks
+func (s *adminService) ListUserTasks(ctx context.Context, actorID, userID string, page, limit int, status string) (*dto.TaskListResponse, error) {
+	page, limit = normalizePage(page, limit)
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := s.audit(ctx, actorID, AuditActionListUserTasks, "user", userID, map[string]interface{}{"page": page, "limit": limit, "status": status}); err != nil {
+		return nil, err
+	}
+
+	tasks, total, err := s.taskRepo.FindByUserID(ctx, userID, page, limit, status, domain.DefaultTaskSort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	taskResponses := make([]dto.TaskResponse, len(tasks))
+	for i := range tasks {
+		taskResponses[i] = toTaskResponse(&tasks[i])
+	}
+
+	return &dto.TaskListResponse{
+		Tasks:      taskResponses,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages(total, limit),
+	}, nil
+}
+
+// TransferTasks moves some or all of a user's tasks to another user
+func (s *adminService) TransferTasks(ctx context.Context, actorID, userID string, req dto.TransferTasksRequest) (*dto.TransferTasksResponse, error) {
+	if req.ToUserID == userID {
+		return nil, domain.InvalidInput("source and target user must differ")
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to find source user: %w", err)
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, req.ToUserID); err != nil {
+		return nil, fmt.Errorf("failed to find target user: %w", err)
+	}
+
+	var transferred int64
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		transferred, err = s.taskRepo.TransferOwnership(ctx, userID, req.ToUserID, req.TaskIDs)
+		if err != nil {
+			return fmt.Errorf("failed to transfer tasks: %w", err)
+		}
+
+		details := map[string]interface{}{
+			"to_user_id":        req.ToUserID,
+			"task_ids":          req.TaskIDs,
+			"transferred_count": transferred,
+		}
+		return s.audit(ctx, actorID, AuditActionTransferTasks, "user", userID, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TransferTasksResponse{TransferredCount: transferred}, nil
+}
+
+// ListAuditLogs returns a page of the audit log, newest first
+func (s *adminService) ListAuditLogs(ctx context.Context, actorID string, page, limit int) (*dto.AuditLogListResponse, error) {
+	page, limit = normalizePage(page, limit)
+
+	if err := s.audit(ctx, actorID, AuditActionListAuditLogs, "audit_log", "", map[string]interface{}{"page": page, "limit": limit}); err != nil {
+		return nil, err
+	}
+
+	entries, total, err := s.auditRepo.List(ctx, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	entryResponses := make([]dto.AuditLogResponse, len(entries))
+	for i, entry := range entries {
+		entryResponses[i] = dto.AuditLogResponse{
+			ID:         fmt.Sprintf("%d", entry.ID),
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			Details:    json.RawMessage(entry.Details),
+			CreatedAt:  entry.CreatedAt.String(),
+		}
+		if entry.ActorUserID != nil {
+			entryResponses[i].ActorUserID = fmt.Sprintf("%d", *entry.ActorUserID)
+		}
+	}
+
+	return &dto.AuditLogListResponse{
+		Entries:    entryResponses,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages(total, limit),
+	}, nil
+}
+
+// toAdminUserResponse converts a user to its admin representation
+func toAdminUserResponse(user *domain.User) dto.AdminUserResponse {
+	resp := dto.AdminUserResponse{
+		ID:        fmt.Sprintf("%d", user.ID),
+		Email:     user.Email,
+		Role:      user.Role,
+		Disabled:  user.IsDisabled(),
+		CreatedAt: user.CreatedAt.String(),
+	}
+	if user.DisabledAt != nil {
+		resp.DisabledAt = user.DisabledAt.String()
+	}
+	return resp
+}
@@ -2,29 +2,85 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/vedologic/task-manager/internal/domain"
 	"github.com/vedologic/task-manager/internal/dto"
 	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"github.com/vedologic/task-manager/pkg/mailer"
 	"github.com/vedologic/task-manager/pkg/utils"
+	"go.uber.org/zap"
 )
 
+// AuthConfig holds the settings of the authentication service
+type AuthConfig struct {
+	JWTSecret      string
+	JWTExpiryHours int
+
+	// RequireVerifiedEmail blocks login until the email address has been verified
+	RequireVerifiedEmail  bool
+	VerificationTokenTTL  time.Duration
+	PasswordResetTokenTTL time.Duration
+
+	// PublicURL is the base URL used for links in emails
+	PublicURL string
+
+	LoginThrottle LoginThrottleConfig
+
+	// TOTPIssuer is shown in authenticator apps
+	TOTPIssuer string
+	// TOTPEncryptionKey encrypts TOTP secrets at rest
+	TOTPEncryptionKey string
+	// TwoFactorChallengeTTL is how long a login may wait for its second factor
+	TwoFactorChallengeTTL time.Duration
+}
+
 // authService implements AuthService interface with business logic
 type authService struct {
-	userRepo       repository.UserRepository
-	jwtSecret      string
-	jwtExpiryHours int
+	userRepo     repository.UserRepository
+	tokenRepo    repository.UserTokenRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	tx           TxManager
+	accountMail  *accountMailer
+	throttle     *loginThrottle
+	cfg          AuthConfig
+	log          *zap.Logger
+
+	// dummyHash is compared against when the email is unknown so response times do not reveal which emails exist
+	dummyHash string
 }
 
 // NewAuthService creates a new authentication service
-func NewAuthService(userRepo repository.UserRepository, jwtSecret string, jwtExpiryHours int) AuthService {
-	return &authService{
-		userRepo:       userRepo,
-		jwtSecret:      jwtSecret,
-		jwtExpiryHours: jwtExpiryHours,
+func NewAuthService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.UserTokenRepository,
+	throttleRepo repository.LoginThrottleRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	tx TxManager,
+	mailer mailer.Mailer,
+	cfg AuthConfig,
+	log *zap.Logger,
+) (AuthService, error) {
+	dummyHash, err := utils.HashPassword("dummy-password-for-timing")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare auth service: %w", err)
 	}
+
+	return &authService{
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		recoveryRepo: recoveryRepo,
+		tx:           tx,
+		accountMail:  newAccountMailer(tokenRepo, mailer, cfg.PublicURL),
+		throttle:     newLoginThrottle(throttleRepo, cfg.LoginThrottle, log),
+		cfg:          cfg,
+		log:          log,
+		dummyHash:    dummyHash,
+	}, nil
 }
 
 // Register registers a new user
@@ -36,7 +92,7 @@ func (s *authService) Register(ctx context.Context, req dto.RegisterRequest) (*d
 	}
 
 	if exists {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, domain.ErrEmailTaken
 	}
 
 	// Hash password
@@ -49,6 +105,7 @@ func (s *authService) Register(ctx context.Context, req dto.RegisterRequest) (*d
 	user := &domain.User{
 		Email:        req.Email,
 		PasswordHash: passwordHash,
+		Role:         domain.UserRoleUser,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -58,45 +115,364 @@ func (s *authService) Register(ctx context.Context, req dto.RegisterRequest) (*d
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(fmt.Sprintf("%d", user.ID), user.Email, s.jwtSecret, s.jwtExpiryHours)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// A failed email must not fail registration; the user can request a new link
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		logger.WithContext(ctx, s.log).Error("Failed to send verification email", zap.Int("user_id", user.ID), zap.Error(err))
 	}
 
-	return &dto.AuthResponse{
-		Token: token,
-		User: dto.UserInfo{
-			ID:    fmt.Sprintf("%d", user.ID),
-			Email: user.Email,
-		},
-	}, nil
+	if s.cfg.RequireVerifiedEmail {
+		return &dto.AuthResponse{User: toUserInfo(user)}, nil
+	}
+
+	return s.issueToken(user)
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *authService) Login(ctx context.Context, req dto.LoginRequest) (*dto.AuthResponse, error) {
+// Login authenticates a user and returns a JWT token, or a challenge token when two-factor
+// authentication is enabled. Failed attempts are throttled per account and per client IP.
+func (s *authService) Login(ctx context.Context, req dto.LoginRequest, clientIP string) (*dto.AuthResponse, error) {
+	now := time.Now()
+	if err := s.throttle.Check(ctx, req.Email, clientIP, now); err != nil {
+		return nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		_ = utils.VerifyPassword(s.dummyHash, req.Password)
+		s.throttle.RecordFailure(ctx, req.Email, clientIP, now)
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	// Verify password
 	if err := utils.VerifyPassword(user.PasswordHash, req.Password); err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		s.throttle.RecordFailure(ctx, req.Email, clientIP, now)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if user.IsDisabled() {
+		return nil, domain.ErrAccountDisabled
+	}
+
+	if s.cfg.RequireVerifiedEmail && !user.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	// Failures are only cleared once every factor has been verified
+	if user.IsTOTPEnabled() {
+		return s.issueChallenge(user)
+	}
+
+	s.throttle.Reset(ctx, req.Email)
+
+	if err := s.cancelScheduledDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(user)
+}
+
+// VerifyEmail consumes an email verification token and marks the address as verified
+func (s *authService) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error {
+	// The token is only used up if the address is marked verified
+	now := time.Now()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userID, err := s.tokenRepo.Consume(ctx, utils.HashToken(req.Token), domain.UserTokenPurposeEmailVerification, now)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, strconv.Itoa(userID), now); err != nil {
+			return fmt.Errorf("failed to verify email: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ResendVerification sends a new verification link to an unverified account. The lookup and the
+// delivery run in the background and failures are only logged, so neither the response time nor the
+// outcome reveals whether the account exists.
+func (s *authService) ResendVerification(ctx context.Context, req dto.EmailRequest) {
+	s.inBackground(ctx, "Failed to resend verification email", func(ctx context.Context) error {
+		user, err := s.findMailRecipient(ctx, req.Email)
+		if err != nil || user == nil || user.IsEmailVerified() {
+			return err
+		}
+
+		if err := s.tokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPurposeEmailVerification, time.Now()); err != nil {
+			return err
+		}
+
+		return s.sendVerificationEmail(ctx, user)
+	})
+}
+
+// ForgotPassword sends a password reset link; earlier reset links stop working. Like
+// ResendVerification it works in the background, so account existence is not revealed.
+func (s *authService) ForgotPassword(ctx context.Context, req dto.EmailRequest) {
+	s.inBackground(ctx, "Failed to send password reset email", func(ctx context.Context) error {
+		user, err := s.findMailRecipient(ctx, req.Email)
+		if err != nil || user == nil {
+			return err
+		}
+
+		if err := s.tokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPurposePasswordReset, time.Now()); err != nil {
+			return err
+		}
+
+		token, err := s.accountMail.createToken(ctx, user, domain.UserTokenPurposePasswordReset, s.cfg.PasswordResetTokenTTL)
+		if err != nil {
+			return err
+		}
+
+		return s.accountMail.send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"Someone requested a password reset for your Task Manager account.\n\n"+
+					"Reset your password: %s\n\n"+
+					"The link expires in %s. If you did not request this, you can ignore this email.\n",
+				s.accountMail.link("/reset-password", token), s.cfg.PasswordResetTokenTTL,
+			),
+		})
+	})
+}
+
+// findMailRecipient returns the account an emailed link may be sent to, or nil for an unknown or
+// disabled address
+func (s *authService) findMailRecipient(ctx context.Context, email string) (*domain.User, error) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, nil
 	}
+	return user, nil
+}
+
+// inBackground runs fn after the request has been answered, detached from its cancellation, and
+// logs its error with msg
+func (s *authService) inBackground(ctx context.Context, msg string, fn func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, backgroundMailTimeout)
+		defer cancel()
+
+		if err := fn(ctx); err != nil {
+			logger.WithContext(ctx, s.log).Error(msg, zap.Error(err))
+		}
+	}()
+}
+
+// ResetPassword consumes a password reset token and sets a new password, revoking every session
+// issued before. Completing a reset also proves ownership of the email address.
+func (s *authService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	// Hash before opening the transaction; bcrypt is slow and holds no database state
+	passwordHash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userID, err := s.tokenRepo.Consume(ctx, utils.HashToken(req.Token), domain.UserTokenPurposePasswordReset, now)
+		if err != nil {
+			return err
+		}
 
+		id := strconv.Itoa(userID)
+		// A reset usually follows a lost or compromised password, so existing sessions must not survive it
+		if _, err := s.userRepo.UpdatePasswordAndRevokeSessions(ctx, id, passwordHash); err != nil {
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, id, now); err != nil {
+			return fmt.Errorf("failed to verify email: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// LoginWithIdentity signs in a user authenticated by an external identity provider.
+// Unknown identities are linked to the account with the same email, or a new account is
+// provisioned, but only when the provider has verified the email address.
+func (s *authService) LoginWithIdentity(ctx context.Context, ext domain.ExternalIdentity) (*dto.AuthResponse, error) {
+	now := time.Now()
+	identity := &domain.UserIdentity{
+		Issuer:    ext.Issuer,
+		Subject:   ext.Subject,
+		Email:     ext.Email,
+		CreatedAt: now,
+	}
+
+	user, err := s.userRepo.FindByIdentity(ctx, ext.Issuer, ext.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		if ext.Email == "" || !ext.EmailVerified {
+			return nil, domain.ErrSingleSignOnFailed.WithMessage("Identity provider did not supply a verified email address")
+		}
+
+		user, err = s.linkOrProvision(ctx, ext, identity, now)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		identity.UserID = user.ID
+		if err := s.userRepo.UpsertIdentity(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.IsDisabled() {
+		return nil, domain.ErrAccountDisabled
+	}
+
+	if user.IsTOTPEnabled() {
+		return s.issueChallenge(user)
+	}
+
+	if err := s.cancelScheduledDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(user)
+}
+
+// linkOrProvision links an identity to the existing account with its verified email, or creates a new account
+func (s *authService) linkOrProvision(ctx context.Context, ext domain.ExternalIdentity, identity *domain.UserIdentity, now time.Time) (*domain.User, error) {
+	exists, err := s.userRepo.ExistsByEmail(ctx, ext.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	if exists {
+		user, err := s.userRepo.FindByEmail(ctx, ext.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		identity.UserID = user.ID
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.userRepo.UpsertIdentity(ctx, identity); err != nil {
+				return err
+			}
+
+			// The provider has verified the address, which also verifies it here
+			if !user.IsEmailVerified() {
+				return s.userRepo.MarkEmailVerified(ctx, fmt.Sprintf("%d", user.ID), now)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !user.IsEmailVerified() {
+			user.EmailVerifiedAt = &now
+		}
+
+		logger.WithContext(ctx, s.log).Info("Linked external identity to existing user", zap.Int("user_id", user.ID), zap.String("issuer", ext.Issuer))
+		return user, nil
+	}
+
+	// Provisioned users have no usable password until they set one through password reset
+	user := &domain.User{
+		Email:           ext.Email,
+		Role:            domain.UserRoleUser,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.userRepo.CreateWithIdentity(ctx, user, identity); err != nil {
+		return nil, err
+	}
+
+	logger.WithContext(ctx, s.log).Info("Provisioned user from external identity", zap.Int("user_id", user.ID), zap.String("issuer", ext.Issuer))
+	return user, nil
+}
+
+// GetActiveUser loads a user and rejects accounts that have been disabled
+func (s *authService) GetActiveUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if user.IsDisabled() {
+		return nil, domain.ErrAccountDisabled
+	}
+
+	return user, nil
+}
+
+// cancelScheduledDeletion restores an account scheduled for deletion; signing in during the
+// grace period is how a user keeps their account
+func (s *authService) cancelScheduledDeletion(ctx context.Context, user *domain.User) error {
+	if !user.IsDeletionScheduled() {
+		return nil
+	}
+
+	if _, err := s.userRepo.CancelDeletion(ctx, strconv.Itoa(user.ID)); err != nil {
+		return fmt.Errorf("failed to restore account: %w", err)
+	}
+	user.DeletionScheduledAt = nil
+
+	logger.WithContext(ctx, s.log).Info("Scheduled account deletion cancelled by sign-in", zap.Int("user_id", user.ID))
+	return nil
+}
+
+// issueToken generates a JWT for the user and builds the auth response
+func (s *authService) issueToken(user *domain.User) (*dto.AuthResponse, error) {
+	return newAuthResponse(user, s.cfg.JWTSecret, s.cfg.JWTExpiryHours)
+}
+
+// newAuthResponse generates a JWT bound to the user's current session version and builds the auth response
+func newAuthResponse(user *domain.User, jwtSecret string, expiryHours int) (*dto.AuthResponse, error) {
 	// Generate JWT token
-	token, err := utils.GenerateToken(fmt.Sprintf("%d", user.ID), user.Email, s.jwtSecret, s.jwtExpiryHours)
+	token, err := utils.GenerateToken(fmt.Sprintf("%d", user.ID), user.Email, string(user.Role), user.SessionVersion, jwtSecret, expiryHours)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return &dto.AuthResponse{
 		Token: token,
-		User: dto.UserInfo{
-			ID:    fmt.Sprintf("%d", user.ID),
-			Email: user.Email,
-		},
+		User:  toUserInfo(user),
 	}, nil
 }
+
+// toUserInfo converts a user to the public representation used in auth responses
+func toUserInfo(user *domain.User) dto.UserInfo {
+	return dto.UserInfo{
+		ID:               fmt.Sprintf("%d", user.ID),
+		Email:            user.Email,
+		Role:             string(user.Role),
+		EmailVerified:    user.IsEmailVerified(),
+		TwoFactorEnabled: user.IsTOTPEnabled(),
+	}
+}
+
+// sendVerificationEmail issues a verification token and emails the link to the user
+func (s *authService) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	token, err := s.accountMail.createToken(ctx, user, domain.UserTokenPurposeEmailVerification, s.cfg.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.accountMail.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Welcome to Task Manager!\n\n"+
+				"Verify your email address: %s\n\n"+
+				"The link expires in %s.\n",
+			s.accountMail.link("/verify-email", token), s.cfg.VerificationTokenTTL,
+		),
+	})
+}
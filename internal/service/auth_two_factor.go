@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/pkg/utils"
+)
+
+const (
+	// challengePurposeTwoFactor marks challenge tokens issued after the password step of a login
+	challengePurposeTwoFactor = "2fa"
+
+	// totpSkew accepts codes from one step either side of the current one to allow for clock drift
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+// EnrollTOTP creates a pending TOTP secret and returns it with an otpauth:// URI for authenticator apps.
+// Enrollment only takes effect after ConfirmTOTP; enrolling again replaces a pending secret.
+func (s *authService) EnrollTOTP(ctx context.Context, userID string) (*dto.TOTPEnrollResponse, error) {
+	user, err := s.GetActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsTOTPEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyActive
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := utils.EncryptString(secret, s.cfg.TOTPEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	if err := s.userRepo.SetTOTPSecret(ctx, userID, &encrypted); err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.cfg.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP verifies a code from the pending secret, enables two-factor authentication and returns recovery codes.
+// Wrong codes count towards the login throttle.
+func (s *authService) ConfirmTOTP(ctx context.Context, userID string, req dto.TOTPCodeRequest, clientIP string) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.GetActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsTOTPEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyActive
+	}
+	if user.TOTPSecret == nil {
+		return nil, domain.ErrTwoFactorNotStarted
+	}
+
+	err = s.throttled(ctx, user, clientIP, func() error {
+		return s.verifyTOTP(ctx, user, req.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Two-factor authentication is only enabled together with its recovery codes
+	var codes *dto.RecoveryCodesResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.EnableTOTP(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = s.replaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP turns off two-factor authentication after re-checking the password and a second factor.
+// A wrong password or code counts towards the login throttle.
+func (s *authService) DisableTOTP(ctx context.Context, userID string, req dto.DisableTOTPRequest, clientIP string) error {
+	user, err := s.GetActiveUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTOTPEnabled() {
+		return domain.ErrTwoFactorNotEnabled
+	}
+
+	err = s.throttled(ctx, user, clientIP, func() error {
+		if err := utils.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+			return domain.ErrInvalidPassword
+		}
+		return s.verifySecondFactor(ctx, user, req.Code, req.Code)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SetTOTPSecret(ctx, userID, nil); err != nil {
+			return err
+		}
+		return s.recoveryRepo.DeleteForUser(ctx, userID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current TOTP code.
+// Wrong codes count towards the login throttle.
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID string, req dto.TOTPCodeRequest, clientIP string) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.GetActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsTOTPEnabled() {
+		return nil, domain.ErrTwoFactorNotEnabled
+	}
+
+	err = s.throttled(ctx, user, clientIP, func() error {
+		return s.verifyTOTP(ctx, user, req.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// VerifyTwoFactorLogin exchanges a login challenge token and a second factor for an access token.
+// Wrong codes count towards the same per-account and per-IP throttle as wrong passwords.
+func (s *authService) VerifyTwoFactorLogin(ctx context.Context, req dto.TwoFactorLoginRequest, clientIP string) (*dto.AuthResponse, error) {
+	claims, err := utils.ValidateChallengeToken(req.ChallengeToken, challengePurposeTwoFactor, s.cfg.JWTSecret)
+	if err != nil {
+		return nil, domain.ErrInvalidChallenge
+	}
+
+	user, err := s.GetActiveUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidChallenge
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, domain.ErrInvalidChallenge
+	}
+
+	now := time.Now()
+	if err := s.throttle.Check(ctx, user.Email, clientIP, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		s.throttle.RecordFailure(ctx, user.Email, clientIP, now)
+		return nil, err
+	}
+
+	s.throttle.Reset(ctx, user.Email)
+
+	if err := s.cancelScheduledDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(user)
+}
+
+// issueChallenge returns the response for a login that still needs a second factor
+func (s *authService) issueChallenge(user *domain.User) (*dto.AuthResponse, error) {
+	token, err := utils.GenerateChallengeToken(fmt.Sprintf("%d", user.ID), challengePurposeTwoFactor, s.cfg.JWTSecret, s.cfg.TwoFactorChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AuthResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		User:              toUserInfo(user),
+	}, nil
+}
+
+// throttled runs a password or code check for a signed-in user under the same per-account and per-IP
+// throttle as logins, so a stolen session cannot be used to guess codes without limit
+func (s *authService) throttled(ctx context.Context, user *domain.User, clientIP string, check func() error) error {
+	now := time.Now()
+	if err := s.throttle.Check(ctx, user.Email, clientIP, now); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, domain.ErrInvalidTwoFactorCode) || errors.Is(err, domain.ErrInvalidPassword) {
+		s.throttle.RecordFailure(ctx, user.Email, clientIP, now)
+	}
+	return err
+}
+
+// verifySecondFactor accepts a TOTP code or, failing that, an unused recovery code
+func (s *authService) verifySecondFactor(ctx context.Context, user *domain.User, code, recoveryCode string) error {
+	if code != "" {
+		if err := s.verifyTOTP(ctx, user, code); err == nil {
+			return nil
+		} else if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			return err
+		}
+	}
+
+	if recoveryCode != "" {
+		used, err := s.recoveryRepo.Consume(ctx, fmt.Sprintf("%d", user.ID), utils.HashToken(normalizeRecoveryCode(recoveryCode)), time.Now())
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	return domain.ErrInvalidTwoFactorCode
+}
+
+// verifyTOTP checks a code against the user's secret and records its time step so it cannot be replayed
+func (s *authService) verifyTOTP(ctx context.Context, user *domain.User, code string) error {
+	secret, err := utils.DecryptString(*user.TOTPSecret, s.cfg.TOTPEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return domain.ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.userRepo.UseTOTPStep(ctx, fmt.Sprintf("%d", user.ID), step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return domain.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes, storing only their hashes
+func (s *authService) replaceRecoveryCodes(ctx context.Context, userID string) (*dto.RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(raw[:5] + "-" + raw[5:10])
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes so codes can be typed loosely
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
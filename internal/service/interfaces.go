@@ -2,18 +2,82 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/vedologic/task-manager/internal/domain"
 	"github.com/vedologic/task-manager/internal/dto"
 )
 
+// TxManager runs a unit of work atomically. Repository calls made with the context passed to fn
+// share one transaction; a nested WithinTx runs in a savepoint of the enclosing transaction.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // AuthService defines the interface for authentication business logic
 type AuthService interface {
 	// Register registers a new user
 	Register(ctx context.Context, req dto.RegisterRequest) (*dto.AuthResponse, error)
 
-	// Login authenticates a user and returns a JWT token
-	Login(ctx context.Context, req dto.LoginRequest) (*dto.AuthResponse, error)
+	// Login authenticates a user and returns a JWT token; failed attempts are throttled per account and client IP
+	Login(ctx context.Context, req dto.LoginRequest, clientIP string) (*dto.AuthResponse, error)
+
+	// LoginWithIdentity signs in, links or provisions a user authenticated by an external identity provider
+	LoginWithIdentity(ctx context.Context, ext domain.ExternalIdentity) (*dto.AuthResponse, error)
+
+	// GetActiveUser returns the user if the account exists and is not disabled
+	GetActiveUser(ctx context.Context, userID string) (*domain.User, error)
+
+	// VerifyEmail consumes an email verification token
+	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error
+
+	// ResendVerification sends a new verification link to an unverified account in the background
+	ResendVerification(ctx context.Context, req dto.EmailRequest)
+
+	// ForgotPassword sends a password reset link in the background
+	ForgotPassword(ctx context.Context, req dto.EmailRequest)
+
+	// ResetPassword consumes a password reset token, sets a new password and revokes existing sessions
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+
+	// EnrollTOTP starts two-factor enrollment and returns the secret and otpauth URI
+	EnrollTOTP(ctx context.Context, userID string) (*dto.TOTPEnrollResponse, error)
+
+	// ConfirmTOTP enables two-factor authentication and returns recovery codes
+	ConfirmTOTP(ctx context.Context, userID string, req dto.TOTPCodeRequest, clientIP string) (*dto.RecoveryCodesResponse, error)
+
+	// DisableTOTP turns off two-factor authentication
+	DisableTOTP(ctx context.Context, userID string, req dto.DisableTOTPRequest, clientIP string) error
+
+	// RegenerateRecoveryCodes replaces the user's recovery codes
+	RegenerateRecoveryCodes(ctx context.Context, userID string, req dto.TOTPCodeRequest, clientIP string) (*dto.RecoveryCodesResponse, error)
+
+	// VerifyTwoFactorLogin completes a two-step login and returns a JWT token
+	VerifyTwoFactorLogin(ctx context.Context, req dto.TwoFactorLoginRequest, clientIP string) (*dto.AuthResponse, error)
+}
+
+// UserService defines the interface for profile and account management of the current user
+type UserService interface {
+	// GetProfile returns the profile of the user
+	GetProfile(ctx context.Context, userID string) (*dto.ProfileResponse, error)
+
+	// UpdateProfile changes the display name, timezone and locale
+	UpdateProfile(ctx context.Context, userID string, req dto.UpdateProfileRequest) (*dto.ProfileResponse, error)
+
+	// RequestEmailChange sends a confirmation link to the new address
+	RequestEmailChange(ctx context.Context, userID string, req dto.ChangeEmailRequest) error
+
+	// ConfirmEmailChange consumes an email change token and applies the new address
+	ConfirmEmailChange(ctx context.Context, req dto.VerifyEmailRequest) error
+
+	// ChangePassword sets a new password, revokes other sessions and returns a new token
+	ChangePassword(ctx context.Context, userID string, req dto.ChangePasswordRequest) (*dto.AuthResponse, error)
+
+	// DeleteAccount schedules the account for deletion after the grace period
+	DeleteAccount(ctx context.Context, userID string, req dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error)
+
+	// PurgeDeletedAccounts permanently removes accounts whose grace period has ended
+	PurgeDeletedAccounts(ctx context.Context) (int64, error)
 }
 
 // TaskService defines the interface for task business logic
@@ -24,8 +88,11 @@ type TaskService interface {
 	// GetByID retrieves a task by ID
 	GetByID(ctx context.Context, taskID string, userID string) (*domain.Task, error)
 
-	// List retrieves all tasks for a user with pagination and filtering
-	List(ctx context.Context, userID string, page, limit int, status string) (*dto.TaskListResponse, error)
+	// List retrieves all tasks for a user with pagination, filtering and sorting
+	List(ctx context.Context, userID string, page, limit int, status string, sort domain.TaskSort) (*dto.TaskListResponse, error)
+
+	// Export streams all tasks for a user matching the filters, without pagination
+	Export(ctx context.Context, userID string, status string, sort domain.TaskSort, dueDatedOnly bool, fn func(*domain.Task) error) error
 
 	// Update updates a task
 	Update(ctx context.Context, taskID string, userID string, req dto.UpdateTaskRequest) (*domain.Task, error)
@@ -33,6 +100,84 @@ type TaskService interface {
 	// Delete deletes a task
 	Delete(ctx context.Context, taskID string, userID string) error
 
-	// BulkComplete marks multiple tasks as done concurrently
+	// BulkComplete marks multiple tasks as done in one transaction, reporting tasks that could not be completed
 	BulkComplete(ctx context.Context, userID string, req dto.BulkCompleteRequest) (*dto.BulkCompleteResponse, error)
+
+	// Stats returns aggregated task metrics for a date range
+	Stats(ctx context.Context, userID string, from, to time.Time, interval string) (*dto.TaskStatsResponse, error)
+
+	// Import validates parsed rows and, unless dry-run, stores them atomically
+	Import(ctx context.Context, userID string, req dto.TaskImportRequest) (*dto.TaskImportResponse, error)
+}
+
+// AdminService defines the interface for administrative operations; every call is audit-logged
+type AdminService interface {
+	// ListUsers returns a page of all users
+	ListUsers(ctx context.Context, actorID string, page, limit int) (*dto.AdminUserListResponse, error)
+
+	// CreateUser creates a user whose email address is already verified
+	CreateUser(ctx context.Context, actorID string, req dto.CreateUserRequest) (*dto.AdminUserResponse, error)
+
+	// ResetUserPassword sets a new password for a user and revokes their sessions
+	ResetUserPassword(ctx context.Context, actorID, userID string, req dto.AdminResetPasswordRequest) error
+
+	// UpdateUserRole changes the role of a user
+	UpdateUserRole(ctx context.Context, actorID, userID string, role domain.UserRole) (*dto.AdminUserResponse, error)
+
+	// DisableUser disables a user account
+	DisableUser(ctx context.Context, actorID, userID string) (*dto.AdminUserResponse, error)
+
+	// EnableUser re-enables a disabled user account
+	EnableUser(ctx context.Context, actorID, userID string) (*dto.AdminUserResponse, error)
+
+	// ListUserTasks returns a page of any user's tasks
+	ListUserTasks(ctx context.Context, actorID, userID string, page, limit int, status string) (*dto.TaskListResponse, error)
+
+	// TransferTasks moves tasks from one user to another
+	TransferTasks(ctx context.Context, actorID, userID string, req dto.TransferTasksRequest) (*dto.TransferTasksResponse, error)
+
+	// ListAuditLogs returns a page of the audit log
+	ListAuditLogs(ctx context.Context, actorID string, page, limit int) (*dto.AuditLogListResponse, error)
+}
+
+// SavedViewService defines the interface for saved view business logic
+type SavedViewService interface {
+	// Create creates a new saved view
+	Create(ctx context.Context, userID string, req dto.SavedViewRequest) (*domain.SavedView, error)
+
+	// GetByID retrieves a saved view by ID
+	GetByID(ctx context.Context, viewID string, userID string) (*domain.SavedView, error)
+
+	// List retrieves all saved views for a user
+	List(ctx context.Context, userID string) (*dto.SavedViewListResponse, error)
+
+	// Update updates a saved view
+	Update(ctx context.Context, viewID string, userID string, req dto.SavedViewRequest) (*domain.SavedView, error)
+
+	// Delete deletes a saved view
+	Delete(ctx context.Context, viewID string, userID string) error
+}
+
+// AccessTokenService defines the interface for personal access token business logic
+type AccessTokenService interface {
+	// Create issues a new personal access token
+	Create(ctx context.Context, userID string, req dto.CreateAccessTokenRequest) (*dto.CreateAccessTokenResponse, error)
+
+	// List returns the user's tokens
+	List(ctx context.Context, userID string) (*dto.AccessTokenListResponse, error)
+
+	// Revoke revokes one of the user's tokens
+	Revoke(ctx context.Context, tokenID string, userID string) error
+
+	// Authenticate resolves a plaintext token to its owner and the token record
+	Authenticate(ctx context.Context, raw string) (*domain.User, *domain.PersonalAccessToken, error)
+}
+
+// OIDCService defines the interface for OpenID Connect single sign-on
+type OIDCService interface {
+	// BeginLogin starts a login and returns the provider URL and the state to keep until the callback
+	BeginLogin(ctx context.Context) (*OIDCLoginStart, error)
+
+	// CompleteLogin finishes a login from the provider callback and returns a JWT token
+	CompleteLogin(ctx context.Context, code, state, sealedState string) (*dto.AuthResponse, error)
 }
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// LoginThrottleConfig holds the brute-force protection settings
+type LoginThrottleConfig struct {
+	// MaxFailures locks an account after this many failures within Window
+	MaxFailures int
+	// IPMaxFailures locks a client IP after this many failures within Window
+	IPMaxFailures int
+	// Window is how long a failure is remembered
+	Window time.Duration
+	// LockoutDuration is how long a locked account or IP stays blocked
+	LockoutDuration time.Duration
+	// DelayBase is the wait imposed after the second consecutive account failure; it doubles with each further failure
+	DelayBase time.Duration
+	// MaxDelay caps the progressive delay
+	MaxDelay time.Duration
+}
+
+// loginThrottle tracks failed logins per account and per client IP in the database,
+// so lockouts survive restarts and apply across replicas
+type loginThrottle struct {
+	repo repository.LoginThrottleRepository
+	cfg  LoginThrottleConfig
+	log  *zap.Logger
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// newLoginThrottle creates a login throttle
+func newLoginThrottle(repo repository.LoginThrottleRepository, cfg LoginThrottleConfig, log *zap.Logger) *loginThrottle {
+	return &loginThrottle{
+		repo:        repo,
+		cfg:         cfg,
+		log:         log,
+		lastCleanup: time.Now(),
+	}
+}
+
+// accountKey normalises an email so case variations share one counter
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns domain.ErrLoginThrottled if the account or the client IP is currently blocked.
+// The error is the same whether or not the email belongs to an account.
+func (t *loginThrottle) Check(ctx context.Context, email, clientIP string, now time.Time) error {
+	var retryAfter time.Duration
+
+	for _, k := range t.keys(email, clientIP) {
+		throttle, err := t.repo.Find(ctx, k.keyType, k.key)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.IsLocked(now) {
+			if wait := throttle.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return domain.ErrLoginThrottled.WithRetryAfter(retryAfter)
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed login against the account and the client IP and applies any delay or lockout
+func (t *loginThrottle) RecordFailure(ctx context.Context, email, clientIP string, now time.Time) {
+	for _, k := range t.keys(email, clientIP) {
+		failures, err := t.repo.RecordFailure(ctx, k.keyType, k.key, now, t.cfg.Window)
+		if err != nil {
+			logger.WithContext(ctx, t.log).Error("Failed to record login failure", zap.String("key_type", string(k.keyType)), zap.Error(err))
+			continue
+		}
+
+		wait := t.blockFor(k.keyType, failures)
+		if wait <= 0 {
+			continue
+		}
+
+		if err := t.repo.Lock(ctx, k.keyType, k.key, now.Add(wait)); err != nil {
+			logger.WithContext(ctx, t.log).Error("Failed to lock login throttle", zap.String("key_type", string(k.keyType)), zap.Error(err))
+			continue
+		}
+
+		if wait >= t.cfg.LockoutDuration {
+			logger.WithContext(ctx, t.log).Warn("Login locked after repeated failures",
+				zap.String("key_type", string(k.keyType)),
+				zap.Int("failures", failures),
+				zap.Duration("locked_for", wait),
+			)
+		}
+	}
+
+	t.cleanup(ctx, now)
+}
+
+// Reset clears the account's failures after a successful login.
+// IP failures are left to expire so one valid account cannot be used to reset an attacker's IP counter.
+func (t *loginThrottle) Reset(ctx context.Context, email string) {
+	if err := t.repo.Reset(ctx, domain.LoginThrottleKeyAccount, accountKey(email)); err != nil {
+		logger.WithContext(ctx, t.log).Error("Failed to reset login throttle", zap.Error(err))
+	}
+}
+
+// blockFor returns how long to block further attempts after the given number of failures
+func (t *loginThrottle) blockFor(keyType domain.LoginThrottleKeyType, failures int) time.Duration {
+	if keyType == domain.LoginThrottleKeyIP {
+		if t.cfg.IPMaxFailures > 0 && failures >= t.cfg.IPMaxFailures {
+			return t.cfg.LockoutDuration
+		}
+		return 0
+	}
+
+	if t.cfg.MaxFailures > 0 && failures >= t.cfg.MaxFailures {
+		return t.cfg.LockoutDuration
+	}
+
+	// The first failure is free; after that the delay doubles until it reaches MaxDelay
+	if failures < 2 || t.cfg.DelayBase <= 0 {
+		return 0
+	}
+	delay := t.cfg.DelayBase
+	for i := 2; i < failures && delay < t.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if t.cfg.MaxDelay > 0 && delay > t.cfg.MaxDelay {
+		delay = t.cfg.MaxDelay
+	}
+
+	return delay
+}
+
+// cleanup removes stale throttle rows at most once per window
+func (t *loginThrottle) cleanup(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	if now.Sub(t.lastCleanup) < t.cfg.Window {
+		t.mu.Unlock()
+		return
+	}
+	t.lastCleanup = now
+	t.mu.Unlock()
+
+	if _, err := t.repo.DeleteStale(ctx, now.Add(-t.cfg.Window), now); err != nil {
+		logger.WithContext(ctx, t.log).Error("Failed to delete stale login throttles", zap.Error(err))
+	}
+}
+
+type throttleKey struct {
+	keyType domain.LoginThrottleKeyType
+	key     string
+}
+
+// keys returns the throttle keys that apply to a login attempt
+func (t *loginThrottle) keys(email, clientIP string) []throttleKey {
+	keys := []throttleKey{{keyType: domain.LoginThrottleKeyAccount, key: accountKey(email)}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{keyType: domain.LoginThrottleKeyIP, key: clientIP})
+	}
+	return keys
+}
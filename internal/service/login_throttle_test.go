@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"go.uber.org/zap"
+)
+
+// memoryThrottleRepository keeps login throttles in a map, following the SQL of the real repository
+type memoryThrottleRepository struct {
+	throttles map[domain.LoginThrottleKeyType]map[string]*domain.LoginThrottle
+}
+
+func newMemoryThrottleRepository() *memoryThrottleRepository {
+	return &memoryThrottleRepository{throttles: map[domain.LoginThrottleKeyType]map[string]*domain.LoginThrottle{
+		domain.LoginThrottleKeyAccount: {},
+		domain.LoginThrottleKeyIP:      {},
+	}}
+}
+
+func (r *memoryThrottleRepository) Find(_ context.Context, keyType domain.LoginThrottleKeyType, key string) (*domain.LoginThrottle, error) {
+	throttle, ok := r.throttles[keyType][key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *memoryThrottleRepository) RecordFailure(_ context.Context, keyType domain.LoginThrottleKeyType, key string, now time.Time, window time.Duration) (int, error) {
+	throttle, ok := r.throttles[keyType][key]
+	if !ok {
+		throttle = &domain.LoginThrottle{KeyType: keyType, Key: key}
+		r.throttles[keyType][key] = throttle
+	}
+	if throttle.LastFailureAt.Before(now.Add(-window)) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	return throttle.Failures, nil
+}
+
+func (r *memoryThrottleRepository) Lock(_ context.Context, keyType domain.LoginThrottleKeyType, key string, until time.Time) error {
+	throttle, ok := r.throttles[keyType][key]
+	if !ok {
+		return nil
+	}
+	if throttle.LockedUntil == nil || until.After(*throttle.LockedUntil) {
+		throttle.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *memoryThrottleRepository) Reset(_ context.Context, keyType domain.LoginThrottleKeyType, key string) error {
+	delete(r.throttles[keyType], key)
+	return nil
+}
+
+func (r *memoryThrottleRepository) DeleteStale(context.Context, time.Time, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestThrottle(repo *memoryThrottleRepository) *loginThrottle {
+	return newLoginThrottle(repo, LoginThrottleConfig{
+		MaxFailures:     5,
+		IPMaxFailures:   8,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		DelayBase:       time.Second,
+		MaxDelay:        4 * time.Second,
+	}, zap.NewNop())
+}
+
+// retryAfter returns how long Check blocks the login, or 0 if it is allowed
+func retryAfter(t *testing.T, throttle *loginThrottle, email, clientIP string, now time.Time) time.Duration {
+	t.Helper()
+
+	err := throttle.Check(context.Background(), email, clientIP, now)
+	if err == nil {
+		return 0
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) || !errors.Is(err, domain.ErrLoginThrottled) {
+		t.Fatalf("Check() error = %v, want %v", err, domain.ErrLoginThrottled)
+	}
+	return domainErr.RetryAfter
+}
+
+func TestLoginThrottleProgressiveDelay(t *testing.T) {
+	throttle := newTestThrottle(newMemoryThrottleRepository())
+	ctx := context.Background()
+	now := time.Now()
+
+	// The first failure is free, then the delay doubles up to MaxDelay until the account locks
+	want := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, delay := range want {
+		throttle.RecordFailure(ctx, "user@example.com", "", now)
+		if got := retryAfter(t, throttle, "user@example.com", "", now); got != delay {
+			t.Errorf("after %d failures: retry after %s, want %s", i+1, got, delay)
+		}
+	}
+
+	if got := retryAfter(t, throttle, "user@example.com", "", now.Add(4*time.Second)); got != 0 {
+		t.Errorf("after the delay: retry after %s, want the login allowed", got)
+	}
+}
+
+func TestLoginThrottleLocksAccount(t *testing.T) {
+	throttle := newTestThrottle(newMemoryThrottleRepository())
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		throttle.RecordFailure(ctx, "user@example.com", "", now)
+	}
+
+	if got := retryAfter(t, throttle, "user@example.com", "", now); got != 15*time.Minute {
+		t.Errorf("retry after %s, want the %s lockout", got, 15*time.Minute)
+	}
+	if got := retryAfter(t, throttle, " USER@example.com ", "", now.Add(time.Minute)); got != 14*time.Minute {
+		t.Errorf("differently cased email: retry after %s, want %s", got, 14*time.Minute)
+	}
+	if got := retryAfter(t, throttle, "other@example.com", "", now); got != 0 {
+		t.Errorf("other account: retry after %s, want the login allowed", got)
+	}
+	if got := retryAfter(t, throttle, "user@example.com", "", now.Add(15*time.Minute)); got != 0 {
+		t.Errorf("after the lockout: retry after %s, want the login allowed", got)
+	}
+}
+
+func TestLoginThrottleLocksClientIP(t *testing.T) {
+	throttle := newTestThrottle(newMemoryThrottleRepository())
+	ctx := context.Background()
+	now := time.Now()
+
+	// Spread over many accounts, so only the IP counter reaches its limit
+	emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}
+	for i := 0; i < 8; i++ {
+		throttle.RecordFailure(ctx, emails[i%len(emails)], "203.0.113.7", now)
+	}
+
+	if got := retryAfter(t, throttle, "new@example.com", "203.0.113.7", now); got != 15*time.Minute {
+		t.Errorf("locked IP: retry after %s, want the %s lockout", got, 15*time.Minute)
+	}
+	if got := retryAfter(t, throttle, "new@example.com", "198.51.100.1", now); got != 0 {
+		t.Errorf("other IP: retry after %s, want the login allowed", got)
+	}
+}
+
+func TestLoginThrottleForgetsOldFailures(t *testing.T) {
+	throttle := newTestThrottle(newMemoryThrottleRepository())
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		throttle.RecordFailure(ctx, "user@example.com", "", now)
+	}
+
+	// A failure after the window starts counting again, so it is free
+	later := now.Add(16 * time.Minute)
+	throttle.RecordFailure(ctx, "user@example.com", "", later)
+	if got := retryAfter(t, throttle, "user@example.com", "", later); got != 0 {
+		t.Errorf("retry after %s, want the login allowed", got)
+	}
+}
+
+func TestLoginThrottleResetClearsOnlyTheAccount(t *testing.T) {
+	repo := newMemoryThrottleRepository()
+	throttle := newTestThrottle(repo)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		throttle.RecordFailure(ctx, "user@example.com", "203.0.113.7", now)
+	}
+	throttle.Reset(ctx, "User@Example.com")
+
+	if got := retryAfter(t, throttle, "user@example.com", "", now); got != 0 {
+		t.Errorf("after reset: retry after %s, want the login allowed", got)
+	}
+	if ip, _ := repo.Find(ctx, domain.LoginThrottleKeyIP, "203.0.113.7"); ip == nil || ip.Failures != 3 {
+		t.Errorf("IP throttle = %+v, want its 3 failures kept", ip)
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/pkg/utils"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig holds the OpenID Connect client settings
+type OIDCConfig struct {
+	// IssuerURL is used to fetch the discovery document (/.well-known/openid-configuration)
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// StateKey encrypts the login state kept in the browser between redirect and callback
+	StateKey string
+	// StateTTL is how long a user may take to log in at the provider
+	StateTTL time.Duration
+}
+
+// OIDCLoginStart is the result of starting a login at the identity provider
+type OIDCLoginStart struct {
+	// AuthURL is the provider's authorization endpoint to redirect the browser to
+	AuthURL string
+	// State must be returned unchanged to CompleteLogin, typically via an HttpOnly cookie
+	State string
+}
+
+// oidcLoginState is the per-login data that must survive the round trip to the provider
+type oidcLoginState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// oidcClaims are the ID token claims used for sign-in
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+}
+
+// oidcService implements OIDCService with the authorization code flow and PKCE
+type oidcService struct {
+	cfg         OIDCConfig
+	authService AuthService
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCService creates a new OpenID Connect login service.
+// The discovery document is fetched on first use so the API can start while the provider is unavailable.
+func NewOIDCService(cfg OIDCConfig, authService AuthService) OIDCService {
+	return &oidcService{
+		cfg:         cfg,
+		authService: authService,
+	}
+}
+
+// BeginLogin creates the state, nonce and PKCE verifier for a login and returns the provider URL
+func (s *oidcService) BeginLogin(ctx context.Context) (*OIDCLoginStart, error) {
+	oauthCfg, _, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := utils.GenerateSecureToken("", 24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateSecureToken("", 24)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState := oidcLoginState{
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(s.cfg.StateTTL).Unix(),
+	}
+
+	payload, err := json.Marshal(loginState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode login state: %w", err)
+	}
+	sealed, err := utils.EncryptString(string(payload), s.cfg.StateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt login state: %w", err)
+	}
+
+	return &OIDCLoginStart{
+		AuthURL: oauthCfg.AuthCodeURL(
+			state,
+			oidc.Nonce(nonce),
+			oauth2.S256ChallengeOption(loginState.Verifier),
+		),
+		State: sealed,
+	}, nil
+}
+
+// CompleteLogin exchanges the authorization code, verifies the ID token and signs the user in
+func (s *oidcService) CompleteLogin(ctx context.Context, code, state, sealedState string) (*dto.AuthResponse, error) {
+	loginState, err := s.openState(sealedState)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(loginState.State), []byte(state)) != 1 {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Login state does not match")
+	}
+
+	oauthCfg, verifier, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthCfg.Exchange(ctx, code, oauth2.VerifierOption(loginState.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Identity provider did not return an ID token")
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(loginState.Nonce)) != 1 {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("ID token nonce does not match")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse id token claims: %w", err)
+	}
+
+	// Providers that omit email_verified are not trusted to have verified the address
+	return s.authService.LoginWithIdentity(ctx, domain.ExternalIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+	})
+}
+
+// openState decrypts and checks the login state created by BeginLogin
+func (s *oidcService) openState(sealed string) (*oidcLoginState, error) {
+	if sealed == "" {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Login state is missing")
+	}
+
+	payload, err := utils.DecryptString(sealed, s.cfg.StateKey)
+	if err != nil {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Login state is invalid")
+	}
+
+	var loginState oidcLoginState
+	if err := json.Unmarshal([]byte(payload), &loginState); err != nil {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Login state is invalid")
+	}
+
+	if time.Now().Unix() > loginState.ExpiresAt {
+		return nil, domain.ErrSingleSignOnFailed.WithMessage("Login state has expired")
+	}
+
+	return &loginState, nil
+}
+
+// client returns the OAuth2 configuration and ID token verifier, fetching the discovery document on first use
+func (s *oidcService) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth != nil {
+		return s.oauth, s.verifier, nil
+	}
+
+	// The provider keeps using this context to refresh signing keys, so it must outlive the request
+	provider, err := oidc.NewProvider(context.WithoutCancel(ctx), s.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load identity provider discovery document: %w", err)
+	}
+
+	scopes := s.cfg.Scopes
+	if !slices.Contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	s.oauth = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+
+	return s.oauth, s.verifier, nil
+}
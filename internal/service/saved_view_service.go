@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/tracing"
+)
+
+// savedViewService implements SavedViewService interface with business logic
+type savedViewService struct {
+	viewRepo repository.SavedViewRepository
+}
+
+// NewSavedViewService creates a new saved view service
+func NewSavedViewService(viewRepo repository.SavedViewRepository) SavedViewService {
+	return &savedViewService{
+		viewRepo: viewRepo,
+	}
+}
+
+// validateSavedView checks the stored task list settings
+func validateSavedView(req dto.SavedViewRequest) error {
+	if req.Status != "" && !req.Status.IsValid() {
+		return domain.InvalidInput(fmt.Sprintf("invalid task status: %s", req.Status))
+	}
+
+	if req.Sort != "" && !req.Sort.IsValid() {
+		return domain.InvalidInput(fmt.Sprintf("invalid sort: %s", req.Sort))
+	}
+
+	return nil
+}
+
+// Create creates a new saved view
+func (s *savedViewService) Create(ctx context.Context, userID string, req dto.SavedViewRequest) (_ *domain.SavedView, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SavedViewService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateSavedView(req); err != nil {
+		return nil, err
+	}
+
+	// Convert userID string to int
+	userIDInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	now := time.Now()
+	view := &domain.SavedView{
+		UserID:    userIDInt,
+		Name:      req.Name,
+		Status:    string(req.Status),
+		Sort:      string(req.Sort),
+		PageSize:  req.PageSize,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.viewRepo.Create(ctx, view); err != nil {
+		return nil, fmt.Errorf("failed to create saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// GetByID retrieves a saved view owned by the user
+func (s *savedViewService) GetByID(ctx context.Context, viewID string, userID string) (_ *domain.SavedView, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SavedViewService.GetByID")
+	defer func() { tracing.End(span, err) }()
+
+	view, err := s.viewRepo.FindByID(ctx, viewID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// List retrieves all saved views for a user
+func (s *savedViewService) List(ctx context.Context, userID string) (_ *dto.SavedViewListResponse, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SavedViewService.List")
+	defer func() { tracing.End(span, err) }()
+
+	views, err := s.viewRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved views: %w", err)
+	}
+
+	return &dto.SavedViewListResponse{Views: views}, nil
+}
+
+// Update replaces the settings of a saved view
+func (s *savedViewService) Update(ctx context.Context, viewID string, userID string, req dto.SavedViewRequest) (_ *domain.SavedView, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SavedViewService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateSavedView(req); err != nil {
+		return nil, err
+	}
+
+	view, err := s.GetByID(ctx, viewID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	view.Name = req.Name
+	view.Status = string(req.Status)
+	view.Sort = string(req.Sort)
+	view.PageSize = req.PageSize
+	view.UpdatedAt = time.Now()
+
+	if err := s.viewRepo.Update(ctx, view); err != nil {
+		return nil, fmt.Errorf("failed to update saved view: %w", err)
+	}
+
+	return view, nil
+}
+
+// Delete deletes a saved view
+func (s *savedViewService) Delete(ctx context.Context, viewID string, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "SavedViewService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.viewRepo.Delete(ctx, viewID, userID); err != nil {
+		return fmt.Errorf("failed to delete saved view: %w", err)
+	}
+
+	return nil
+}
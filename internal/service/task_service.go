@@ -4,31 +4,50 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/vedologic/task-manager/internal/domain"
 	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/metrics"
 	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/tracing"
+)
+
+const (
+	// StatsIntervalDay groups task statistics per day
+	StatsIntervalDay = "day"
+	// StatsIntervalWeek groups task statistics per ISO week
+	StatsIntervalWeek = "week"
+
+	// maxStatsPeriods caps the number of buckets in a statistics timeline
+	maxStatsPeriods = 366
+
+	// tracerName identifies spans started by the service layer
+	tracerName = "github.com/vedologic/task-manager/internal/service"
 )
 
 // taskService implements TaskService interface with business logic
 type taskService struct {
 	taskRepo repository.TaskRepository
+	tx       TxManager
 }
 
 // NewTaskService creates a new task service
-func NewTaskService(taskRepo repository.TaskRepository) TaskService {
+func NewTaskService(taskRepo repository.TaskRepository, tx TxManager) TaskService {
 	return &taskService{
 		taskRepo: taskRepo,
+		tx:       tx,
 	}
 }
 
 // Create creates a new task
-func (s *taskService) Create(ctx context.Context, userID string, req dto.CreateTaskRequest) (*domain.Task, error) {
+func (s *taskService) Create(ctx context.Context, userID string, req dto.CreateTaskRequest) (_ *domain.Task, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Create")
+	defer func() { tracing.End(span, err) }()
+
 	// Validate status
 	if !req.Status.IsValid() {
-		return nil, fmt.Errorf("invalid task status: %s", req.Status)
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid task status: %s", req.Status))
 	}
 
 	// Convert userID string to int
@@ -38,25 +57,55 @@ func (s *taskService) Create(ctx context.Context, userID string, req dto.CreateT
 	}
 
 	// Create task entity
+	now := time.Now()
 	task := &domain.Task{
 		UserID:      userIDInt,
 		Title:       req.Title,
 		Description: req.Description,
-		Status:      req.Status,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		DueDate:     req.DueDate,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
+	task.SetStatus(req.Status, now)
 
 	// Save to repository
 	if err := s.taskRepo.Create(ctx, task); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
+	metrics.TasksCreated.WithLabelValues(metrics.SourceAPI).Inc()
+	if task.Status == domain.TaskStatusDone {
+		metrics.TasksCompleted.Inc()
+	}
+
 	return task, nil
 }
 
+// toTaskResponse converts a task to its list representation
+func toTaskResponse(task *domain.Task) dto.TaskResponse {
+	resp := dto.TaskResponse{
+		ID:          fmt.Sprintf("%d", task.ID),
+		UserID:      fmt.Sprintf("%d", task.UserID),
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		CreatedAt:   task.CreatedAt.String(),
+		UpdatedAt:   task.UpdatedAt.String(),
+	}
+	if task.DueDate != nil {
+		resp.DueDate = task.DueDate.String()
+	}
+	if task.CompletedAt != nil {
+		resp.CompletedAt = task.CompletedAt.String()
+	}
+	return resp
+}
+
 // GetByID retrieves a task by ID
-func (s *taskService) GetByID(ctx context.Context, taskID string, userID string) (*domain.Task, error) {
+func (s *taskService) GetByID(ctx context.Context, taskID string, userID string) (_ *domain.Task, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.GetByID")
+	defer func() { tracing.End(span, err) }()
+
 	// Convert userID to int
 	userIDInt, err := strconv.Atoi(userID)
 	if err != nil {
@@ -69,16 +118,19 @@ func (s *taskService) GetByID(ctx context.Context, taskID string, userID string)
 		return nil, fmt.Errorf("failed to find task: %w", err)
 	}
 
-	// Verify ownership
+	// Tasks of other users are reported as missing so their IDs cannot be probed
 	if task.UserID != userIDInt {
-		return nil, fmt.Errorf("access denied: task does not belong to user")
+		return nil, domain.ErrTaskNotFound
 	}
 
 	return task, nil
 }
 
-// List retrieves all tasks for a user with pagination and filtering
-func (s *taskService) List(ctx context.Context, userID string, page, limit int, status string) (*dto.TaskListResponse, error) {
+// List retrieves all tasks for a user with pagination, filtering and sorting
+func (s *taskService) List(ctx context.Context, userID string, page, limit int, status string, sort domain.TaskSort) (_ *dto.TaskListResponse, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.List")
+	defer func() { tracing.End(span, err) }()
+
 	// Validate pagination
 	if page < 1 {
 		page = 1
@@ -87,43 +139,72 @@ func (s *taskService) List(ctx context.Context, userID string, page, limit int,
 		limit = 10
 	}
 
+	// Validate sort
+	if sort == "" {
+		sort = domain.DefaultTaskSort
+	}
+	if !sort.IsValid() {
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid sort: %s", sort))
+	}
+
 	// Get tasks from repository
-	tasks, total, err := s.taskRepo.FindByUserID(ctx, userID, page, limit, status)
+	tasks, total, err := s.taskRepo.FindByUserID(ctx, userID, page, limit, status, sort)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 
 	// Convert to response DTOs
 	taskResponses := make([]dto.TaskResponse, len(tasks))
-	for i, task := range tasks {
-		taskResponses[i] = dto.TaskResponse{
-			ID:          fmt.Sprintf("%d", task.ID),
-			UserID:      fmt.Sprintf("%d", task.UserID),
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			CreatedAt:   task.CreatedAt.String(),
-			UpdatedAt:   task.UpdatedAt.String(),
-		}
+	for i := range tasks {
+		taskResponses[i] = toTaskResponse(&tasks[i])
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-
 	return &dto.TaskListResponse{
 		Tasks:      taskResponses,
 		TotalCount: total,
 		Page:       page,
 		Limit:      limit,
-		TotalPages: totalPages,
+		TotalPages: totalPages(total, limit),
 	}, nil
 }
 
+// Export streams all of a user's tasks matching the status filter to fn in the given order, without paginating
+func (s *taskService) Export(ctx context.Context, userID string, status string, sort domain.TaskSort, dueDatedOnly bool, fn func(*domain.Task) error) (err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Export")
+	defer func() { tracing.End(span, err) }()
+
+	if status != "" && !domain.TaskStatus(status).IsValid() {
+		return domain.InvalidInput(fmt.Sprintf("invalid task status: %s", status))
+	}
+
+	if sort == "" {
+		sort = domain.DefaultTaskSort
+	}
+	if !sort.IsValid() {
+		return domain.InvalidInput(fmt.Sprintf("invalid sort: %s", sort))
+	}
+
+	filter := repository.TaskExportFilter{
+		Status:      status,
+		Sort:        sort,
+		WithDueDate: dueDatedOnly,
+	}
+
+	if err := s.taskRepo.StreamByUserID(ctx, userID, filter, fn); err != nil {
+		return fmt.Errorf("failed to export tasks: %w", err)
+	}
+
+	return nil
+}
+
 // Update updates a task
-func (s *taskService) Update(ctx context.Context, taskID string, userID string, req dto.UpdateTaskRequest) (*domain.Task, error) {
+func (s *taskService) Update(ctx context.Context, taskID string, userID string, req dto.UpdateTaskRequest) (_ *domain.Task, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Update")
+	defer func() { tracing.End(span, err) }()
+
 	// Validate status
 	if !req.Status.IsValid() {
-		return nil, fmt.Errorf("invalid task status: %s", req.Status)
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid task status: %s", req.Status))
 	}
 
 	// Get existing task
@@ -132,22 +213,32 @@ func (s *taskService) Update(ctx context.Context, taskID string, userID string,
 		return nil, err
 	}
 
+	wasDone := task.Status == domain.TaskStatusDone
+
 	// Update fields
 	task.Title = req.Title
 	task.Description = req.Description
-	task.Status = req.Status
+	task.DueDate = req.DueDate
 	task.UpdatedAt = time.Now()
+	task.SetStatus(req.Status, task.UpdatedAt)
 
 	// Save to repository
 	if err := s.taskRepo.Update(ctx, task); err != nil {
 		return nil, fmt.Errorf("failed to update task: %w", err)
 	}
 
+	if !wasDone && task.Status == domain.TaskStatusDone {
+		metrics.TasksCompleted.Inc()
+	}
+
 	return task, nil
 }
 
 // Delete deletes a task
-func (s *taskService) Delete(ctx context.Context, taskID string, userID string) error {
+func (s *taskService) Delete(ctx context.Context, taskID string, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Delete")
+	defer func() { tracing.End(span, err) }()
+
 	// Verify task exists and belongs to user
 	if _, err := s.GetByID(ctx, taskID, userID); err != nil {
 		return err
@@ -161,10 +252,14 @@ func (s *taskService) Delete(ctx context.Context, taskID string, userID string)
 	return nil
 }
 
-// BulkComplete marks multiple tasks as done concurrently using goroutines and channels
-func (s *taskService) BulkComplete(ctx context.Context, userID string, req dto.BulkCompleteRequest) (*dto.BulkCompleteResponse, error) {
+// BulkComplete marks multiple tasks as done in one transaction. Each task is completed in its own
+// savepoint, so a task that fails is reported without undoing the others.
+func (s *taskService) BulkComplete(ctx context.Context, userID string, req dto.BulkCompleteRequest) (_ *dto.BulkCompleteResponse, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.BulkComplete")
+	defer func() { tracing.End(span, err) }()
+
 	if len(req.TaskIDs) == 0 {
-		return nil, fmt.Errorf("no task IDs provided")
+		return nil, domain.InvalidInput("no task IDs provided")
 	}
 
 	// Convert userID string to int
@@ -173,84 +268,184 @@ func (s *taskService) BulkComplete(ctx context.Context, userID string, req dto.B
 		return nil, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	// Number of concurrent workers
-	numWorkers := 5
-	taskIDsChan := make(chan string, numWorkers)
-	resultsChan := make(chan error, len(req.TaskIDs))
+	var successCount, newlyCompleted int
+	var failedIDs []string
 
-	var wg sync.WaitGroup
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// The transaction may be run again after a transient failure, so count from scratch
+		successCount, newlyCompleted = 0, 0
+		failedIDs = []string{}
 
-	// Start worker goroutines
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for taskID := range taskIDsChan {
+		for _, taskID := range req.TaskIDs {
+			var wasDone bool
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
 				// Verify ownership
 				existingTask, err := s.GetByID(ctx, taskID, userID)
 				if err != nil {
-					resultsChan <- fmt.Errorf("task %s: %w", taskID, err)
-					continue
-				}
-
-				// Convert taskID to int
-				taskIDInt, err := strconv.Atoi(taskID)
-				if err != nil {
-					resultsChan <- fmt.Errorf("invalid task ID %s: %w", taskID, err)
-					continue
+					return fmt.Errorf("task %s: %w", taskID, err)
 				}
+				wasDone = existingTask.Status == domain.TaskStatusDone
 
 				// Update only status while preserving title and description
 				task := &domain.Task{
-					ID:          taskIDInt,
+					ID:          existingTask.ID,
 					UserID:      userIDInt,
 					Title:       existingTask.Title,
 					Description: existingTask.Description,
-					Status:      domain.TaskStatusDone,
+					DueDate:     existingTask.DueDate,
+					CompletedAt: existingTask.CompletedAt,
 					UpdatedAt:   time.Now(),
 				}
+				task.SetStatus(domain.TaskStatusDone, task.UpdatedAt)
 
 				if err := s.taskRepo.Update(ctx, task); err != nil {
-					resultsChan <- fmt.Errorf("failed to update task %s: %w", taskID, err)
-				} else {
-					resultsChan <- nil
+					return fmt.Errorf("failed to update task %s: %w", taskID, err)
 				}
+				return nil
+			})
+			if err != nil {
+				failedIDs = append(failedIDs, taskID)
+				continue
 			}
-		}()
-	}
 
-	// Send task IDs to channel
-	go func() {
-		for _, taskID := range req.TaskIDs {
-			taskIDsChan <- taskID
-		}
-		close(taskIDsChan)
-	}()
-
-	// Wait for all workers to complete
-	wg.Wait()
-	close(resultsChan)
-
-	// Collect results
-	successCount := 0
-	failedIDs := []string{}
-
-	for i, taskID := range req.TaskIDs {
-		err := <-resultsChan
-		if err != nil {
-			failedIDs = append(failedIDs, taskID)
-		} else {
 			successCount++
+			if !wasDone {
+				newlyCompleted++
+			}
 		}
-		// Ensure we process all results
-		if i+1 < len(req.TaskIDs) {
-			<-resultsChan
-		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete tasks: %w", err)
 	}
 
+	metrics.TasksCompleted.Add(float64(newlyCompleted))
+	metrics.TaskBulkCompleteFailures.Add(float64(len(failedIDs)))
+
 	return &dto.BulkCompleteResponse{
 		SuccessCount: successCount,
 		FailedCount:  len(failedIDs),
 		FailedIDs:    failedIDs,
 	}, nil
 }
+
+// Import validates every row and stores the whole batch only when all rows are valid
+func (s *taskService) Import(ctx context.Context, userID string, req dto.TaskImportRequest) (_ *dto.TaskImportResponse, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Import")
+	defer func() { tracing.End(span, err) }()
+
+	if len(req.Rows) == 0 {
+		return nil, domain.InvalidInput("import file contains no rows")
+	}
+
+	// Convert userID string to int
+	userIDInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	resp := &dto.TaskImportResponse{
+		DryRun:    req.DryRun,
+		TotalRows: len(req.Rows),
+	}
+
+	now := time.Now()
+	tasks := make([]domain.Task, 0, len(req.Rows))
+
+	for _, row := range req.Rows {
+		rowErrors := row.Errors
+		if row.Task.Status != "" && !row.Task.Status.IsValid() {
+			rowErrors = append(rowErrors, fmt.Sprintf("invalid task status: %s", row.Task.Status))
+		}
+
+		if len(rowErrors) > 0 {
+			resp.Errors = append(resp.Errors, dto.TaskImportRowError{
+				Line:   row.Line,
+				Errors: rowErrors,
+			})
+			continue
+		}
+
+		task := domain.Task{
+			UserID:      userIDInt,
+			Title:       row.Task.Title,
+			Description: row.Task.Description,
+			DueDate:     row.Task.DueDate,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		task.SetStatus(row.Task.Status, now)
+		tasks = append(tasks, task)
+	}
+
+	resp.ValidRows = len(tasks)
+	resp.InvalidRows = len(resp.Errors)
+
+	// Nothing is written on dry-run or when any row is invalid
+	if req.DryRun || resp.InvalidRows > 0 {
+		return resp, nil
+	}
+
+	imported, err := s.taskRepo.CreateBatch(ctx, tasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import tasks: %w", err)
+	}
+	resp.ImportedCount = imported
+	metrics.TasksCreated.WithLabelValues(metrics.SourceImport).Add(float64(imported))
+
+	return resp, nil
+}
+
+// Stats returns task counts, created/completed timeline and completion metrics for a date range
+func (s *taskService) Stats(ctx context.Context, userID string, from, to time.Time, interval string) (_ *dto.TaskStatsResponse, err error) {
+	ctx, span := tracing.Start(ctx, tracerName, "TaskService.Stats")
+	defer func() { tracing.End(span, err) }()
+
+	if interval != StatsIntervalDay && interval != StatsIntervalWeek {
+		return nil, domain.InvalidInput(fmt.Sprintf("invalid interval: %s", interval))
+	}
+
+	if !from.Before(to) {
+		return nil, domain.InvalidInput("from must be before to")
+	}
+
+	periodLength := 24 * time.Hour
+	if interval == StatsIntervalWeek {
+		periodLength = 7 * periodLength
+	}
+	if to.Sub(from)/periodLength > maxStatsPeriods {
+		return nil, domain.InvalidInput(fmt.Sprintf("date range too large: at most %d %ss", maxStatsPeriods, interval))
+	}
+
+	stats, err := s.taskRepo.GetStats(ctx, userID, from, to, interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task stats: %w", err)
+	}
+
+	counts := dto.TaskStatusCounts{
+		Todo:       stats.StatusCounts[domain.TaskStatusTodo],
+		InProgress: stats.StatusCounts[domain.TaskStatusInProgress],
+		Done:       stats.StatusCounts[domain.TaskStatusDone],
+	}
+	counts.Total = counts.Todo + counts.InProgress + counts.Done
+
+	timeline := make([]dto.TaskStatsPeriod, len(stats.Timeline))
+	for i, period := range stats.Timeline {
+		timeline[i] = dto.TaskStatsPeriod{
+			PeriodStart: period.PeriodStart.UTC().Format(time.RFC3339),
+			Created:     period.Created,
+			Completed:   period.Completed,
+		}
+	}
+
+	return &dto.TaskStatsResponse{
+		From:                 from.UTC().Format(time.RFC3339),
+		To:                   to.UTC().Format(time.RFC3339),
+		Interval:             interval,
+		StatusCounts:         counts,
+		OverdueCount:         stats.OverdueCount,
+		AvgTimeToDoneSeconds: stats.AvgTimeToDoneSeconds,
+		Timeline:             timeline,
+	}, nil
+}
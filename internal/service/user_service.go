@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+	"github.com/vedologic/task-manager/internal/repository"
+	"github.com/vedologic/task-manager/pkg/logger"
+	"github.com/vedologic/task-manager/pkg/mailer"
+	"github.com/vedologic/task-manager/pkg/utils"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+)
+
+// UserConfig holds the settings for profile and account management
+type UserConfig struct {
+	JWTSecret      string
+	JWTExpiryHours int
+	PublicURL      string
+	// EmailChangeTokenTTL is how long the confirmation link sent to a new address stays valid
+	EmailChangeTokenTTL time.Duration
+	// ReauthWindow is how recently accounts without a password must have signed in to change their
+	// email or delete the account
+	ReauthWindow time.Duration
+	// DeletionGracePeriod is how long a deleted account can still be restored by signing in
+	DeletionGracePeriod time.Duration
+}
+
+// userService implements UserService interface with business logic
+type userService struct {
+	userRepo    repository.UserRepository
+	tokenRepo   repository.UserTokenRepository
+	tx          TxManager
+	accountMail *accountMailer
+	cfg         UserConfig
+	log         *zap.Logger
+}
+
+// NewUserService creates a new user service
+func NewUserService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.UserTokenRepository,
+	tx TxManager,
+	mailer mailer.Mailer,
+	cfg UserConfig,
+	log *zap.Logger,
+) UserService {
+	return &userService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		tx:          tx,
+		accountMail: newAccountMailer(tokenRepo, mailer, cfg.PublicURL),
+		cfg:         cfg,
+		log:         log,
+	}
+}
+
+// GetProfile returns the profile of the current user
+func (s *userService) GetProfile(ctx context.Context, userID string) (*dto.ProfileResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return toProfileResponse(user), nil
+}
+
+// UpdateProfile changes the display name, timezone and locale; omitted fields are kept
+func (s *userService) UpdateProfile(ctx context.Context, userID string, req dto.UpdateProfileRequest) (*dto.ProfileResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+
+	if req.Timezone != nil {
+		timezone, err := normalizeTimezone(*req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		user.Timezone = timezone
+	}
+
+	if req.Locale != nil {
+		locale, err := normalizeLocale(*req.Locale)
+		if err != nil {
+			return nil, err
+		}
+		user.Locale = locale
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return toProfileResponse(user), nil
+}
+
+// RequestEmailChange sends a confirmation link to the new address. The email only changes once
+// the link is used, and a newer request replaces any earlier one.
+func (s *userService) RequestEmailChange(ctx context.Context, userID string, req dto.ChangeEmailRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := s.verifyReauthentication(user, req.Password, req.AuthenticatedAt); err != nil {
+		return err
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return domain.InvalidInput("new email must differ from the current email")
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrEmailTaken
+	}
+
+	var token string
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPurposeEmailChange, time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.userRepo.SetPendingEmail(ctx, userID, &newEmail); err != nil {
+			return err
+		}
+
+		var err error
+		token, err = s.accountMail.createToken(ctx, user, domain.UserTokenPurposeEmailChange, s.cfg.EmailChangeTokenTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.accountMail.send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"You asked to change the email address of your Task Manager account to this address.\n\n"+
+				"Confirm the change: %s\n\n"+
+				"The link expires in %s. If you did not request this, you can ignore this email.\n",
+			s.accountMail.link("/confirm-email-change", token), s.cfg.EmailChangeTokenTTL,
+		),
+	})
+}
+
+// ConfirmEmailChange consumes an email change token, applies the pending address as verified
+// and notifies the previous address
+func (s *userService) ConfirmEmailChange(ctx context.Context, req dto.VerifyEmailRequest) error {
+	now := time.Now()
+	var user *domain.User
+	var newEmail string
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userID, err := s.tokenRepo.Consume(ctx, utils.HashToken(req.Token), domain.UserTokenPurposeEmailChange, now)
+		if err != nil {
+			return err
+		}
+
+		id := strconv.Itoa(userID)
+		user, err = s.userRepo.FindByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to find user: %w", err)
+		}
+		if user.PendingEmail == nil {
+			return domain.ErrEmailChangeCancelled
+		}
+
+		newEmail = *user.PendingEmail
+		applied, err := s.userRepo.ApplyEmailChange(ctx, id, newEmail, now)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			return domain.ErrEmailChangeCancelled
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.WithContext(ctx, s.log).Info("User email changed", zap.Int("user_id", user.ID))
+
+	// The account change has been made; a failed notification must not report it as failed
+	if err := s.accountMail.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf(
+			"The email address of your Task Manager account was changed to %s.\n\n"+
+				"If you did not make this change, contact support immediately.\n",
+			newEmail,
+		),
+	}); err != nil {
+		logger.WithContext(ctx, s.log).Warn("Failed to notify previous email address", zap.Int("user_id", user.ID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// ChangePassword sets a new password after checking the current one. Every other session is
+// revoked, and a new token for the calling session is returned.
+func (s *userService) ChangePassword(ctx context.Context, userID string, req dto.ChangePasswordRequest) (*dto.AuthResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if !user.HasPassword() {
+		return nil, domain.ErrNoPassword
+	}
+	if err := verifyCurrentPassword(user, req.CurrentPassword); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var sessionVersion int
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		sessionVersion, err = s.userRepo.UpdatePasswordAndRevokeSessions(ctx, userID, passwordHash)
+		if err != nil {
+			return err
+		}
+
+		// Outstanding reset links would otherwise still allow the old owner back in
+		return s.tokenRepo.InvalidateForUser(ctx, user.ID, domain.UserTokenPurposePasswordReset, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = passwordHash
+	user.SessionVersion = sessionVersion
+
+	logger.WithContext(ctx, s.log).Info("User password changed and sessions revoked", zap.Int("user_id", user.ID))
+
+	return newAuthResponse(user, s.cfg.JWTSecret, s.cfg.JWTExpiryHours)
+}
+
+// DeleteAccount schedules the account for deletion after the grace period and signs the user out
+// everywhere. Signing in again before the deletion time restores the account.
+func (s *userService) DeleteAccount(ctx context.Context, userID string, req dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := s.verifyReauthentication(user, req.Password, req.AuthenticatedAt); err != nil {
+		return nil, err
+	}
+
+	deleteAt := time.Now().Add(s.cfg.DeletionGracePeriod)
+	if user.IsDeletionScheduled() {
+		deleteAt = *user.DeletionScheduledAt
+	}
+
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, deleteAt); err != nil {
+		return nil, err
+	}
+
+	logger.WithContext(ctx, s.log).Info("User account scheduled for deletion", zap.Int("user_id", user.ID), zap.Time("delete_at", deleteAt))
+
+	if err := s.accountMail.send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf(
+			"Your Task Manager account and all of its data will be deleted on %s.\n\n"+
+				"To keep your account, sign in again before then: %s\n",
+			deleteAt.UTC().Format(time.RFC1123), strings.TrimRight(s.cfg.PublicURL, "/")+"/login",
+		),
+	}); err != nil {
+		logger.WithContext(ctx, s.log).Warn("Failed to send account deletion notice", zap.Int("user_id", user.ID), zap.Error(err))
+	}
+
+	return &dto.AccountDeletionResponse{
+		DeletionScheduledAt: deleteAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// PurgeDeletedAccounts permanently removes accounts whose grace period has ended
+func (s *userService) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	deleted, err := s.userRepo.DeleteScheduled(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		logger.WithContext(ctx, s.log).Info("Purged deleted accounts", zap.Int64("count", deleted))
+	}
+
+	return deleted, nil
+}
+
+// verifyReauthentication confirms a sensitive change. Accounts with a password confirm it with the
+// password; accounts provisioned by single sign-on have none, so their session must come from a sign-in
+// within the reauthentication window.
+func (s *userService) verifyReauthentication(user *domain.User, password string, authenticatedAt time.Time) error {
+	if user.HasPassword() {
+		return verifyCurrentPassword(user, password)
+	}
+
+	if authenticatedAt.IsZero() || time.Since(authenticatedAt) > s.cfg.ReauthWindow {
+		return domain.ErrReauthenticationRequired
+	}
+
+	return nil
+}
+
+// verifyCurrentPassword checks the password of an account that has one
+func verifyCurrentPassword(user *domain.User, password string) error {
+	if password == "" || utils.VerifyPassword(user.PasswordHash, password) != nil {
+		return domain.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// normalizeTimezone validates an IANA time zone name
+func normalizeTimezone(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	// "Local" depends on the server and is not a meaningful user preference
+	if name == "" || name == "Local" {
+		return "", domain.InvalidInput(fmt.Sprintf("invalid timezone: %q", name))
+	}
+
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", domain.InvalidInput(fmt.Sprintf("invalid timezone: %q", name))
+	}
+
+	return name, nil
+}
+
+// normalizeLocale validates a BCP 47 language tag and returns its canonical form
+func normalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil {
+		return "", domain.InvalidInput(fmt.Sprintf("invalid locale: %q", locale))
+	}
+
+	return tag.String(), nil
+}
+
+// toProfileResponse converts a user to the profile returned by the /me endpoints
+func toProfileResponse(user *domain.User) *dto.ProfileResponse {
+	resp := &dto.ProfileResponse{
+		ID:               strconv.Itoa(user.ID),
+		Email:            user.Email,
+		EmailVerified:    user.IsEmailVerified(),
+		DisplayName:      user.DisplayName,
+		Timezone:         user.Timezone,
+		Locale:           user.Locale,
+		Role:             string(user.Role),
+		TwoFactorEnabled: user.IsTOTPEnabled(),
+		HasPassword:      user.HasPassword(),
+		CreatedAt:        user.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if user.PendingEmail != nil {
+		resp.PendingEmail = *user.PendingEmail
+	}
+	if user.DeletionScheduledAt != nil {
+		resp.DeletionScheduledAt = user.DeletionScheduledAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
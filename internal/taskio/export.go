@@ -0,0 +1,220 @@
+package taskio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vedologic/task-manager/internal/domain"
+)
+
+// File formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatICS    = "ics"
+)
+
+const (
+	icsTimeFormat   = "20060102T150405Z"
+	icsMaxLineBytes = 75
+)
+
+// Exporter encodes a stream of tasks in a single export format
+type Exporter interface {
+	// ContentType returns the MIME type of the encoded output
+	ContentType() string
+	// Extension returns the file extension used in Content-Disposition
+	Extension() string
+	// DueDatedOnly reports whether only tasks with a due date are exported
+	DueDatedOnly() bool
+	// Begin writes any preamble
+	Begin() error
+	// Write encodes a single task
+	Write(task *domain.Task) error
+	// End writes any trailer and flushes buffered output
+	End() error
+	// Flush pushes buffered output to the underlying writer
+	Flush() error
+}
+
+// NewExporter returns the exporter for the requested format
+func NewExporter(format string, w io.Writer) (Exporter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvTaskExporter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonTaskExporter{enc: json.NewEncoder(w)}, nil
+	case FormatICS:
+		return &icsTaskExporter{w: w, stamp: time.Now().UTC()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// formatOptionalTime formats a nullable timestamp as RFC 3339, or an empty string
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvTaskExporter writes tasks as CSV with a header row
+type csvTaskExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvTaskExporter) ContentType() string { return "text/csv; charset=utf-8" }
+func (e *csvTaskExporter) Extension() string   { return "csv" }
+func (e *csvTaskExporter) DueDatedOnly() bool  { return false }
+
+func (e *csvTaskExporter) Begin() error {
+	return e.w.Write([]string{"id", "title", "description", "status", "due_date", "completed_at", "created_at", "updated_at"})
+}
+
+func (e *csvTaskExporter) Write(task *domain.Task) error {
+	return e.w.Write([]string{
+		strconv.Itoa(task.ID),
+		task.Title,
+		task.Description,
+		string(task.Status),
+		formatOptionalTime(task.DueDate),
+		formatOptionalTime(task.CompletedAt),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvTaskExporter) End() error { return e.Flush() }
+
+func (e *csvTaskExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonTaskExporter writes one JSON task object per line
+type ndjsonTaskExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonTaskExporter) ContentType() string           { return "application/x-ndjson" }
+func (e *ndjsonTaskExporter) Extension() string             { return "ndjson" }
+func (e *ndjsonTaskExporter) DueDatedOnly() bool            { return false }
+func (e *ndjsonTaskExporter) Begin() error                  { return nil }
+func (e *ndjsonTaskExporter) Write(task *domain.Task) error { return e.enc.Encode(task) }
+func (e *ndjsonTaskExporter) End() error                    { return nil }
+func (e *ndjsonTaskExporter) Flush() error                  { return nil }
+
+// icsTaskExporter writes due-dated tasks as VTODO components of an iCalendar (RFC 5545) stream
+type icsTaskExporter struct {
+	w     io.Writer
+	stamp time.Time
+}
+
+func (e *icsTaskExporter) ContentType() string { return "text/calendar; charset=utf-8" }
+func (e *icsTaskExporter) Extension() string   { return "ics" }
+func (e *icsTaskExporter) DueDatedOnly() bool  { return true }
+func (e *icsTaskExporter) Flush() error        { return nil }
+
+func (e *icsTaskExporter) Begin() error {
+	return e.writeLines(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Task Manager//Task Export//EN",
+		"CALSCALE:GREGORIAN",
+	)
+}
+
+func (e *icsTaskExporter) Write(task *domain.Task) error {
+	lines := []string{
+		"BEGIN:VTODO",
+		fmt.Sprintf("UID:task-%d@task-manager", task.ID),
+		"DTSTAMP:" + e.stamp.Format(icsTimeFormat),
+		"CREATED:" + task.CreatedAt.UTC().Format(icsTimeFormat),
+		"LAST-MODIFIED:" + task.UpdatedAt.UTC().Format(icsTimeFormat),
+		"SUMMARY:" + escapeICSText(task.Title),
+	}
+	if task.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeICSText(task.Description))
+	}
+	if task.DueDate != nil {
+		lines = append(lines, "DUE:"+task.DueDate.UTC().Format(icsTimeFormat))
+	}
+	lines = append(lines, "STATUS:"+icsStatus(task.Status))
+	if task.CompletedAt != nil {
+		lines = append(lines, "COMPLETED:"+task.CompletedAt.UTC().Format(icsTimeFormat))
+	}
+	lines = append(lines, "END:VTODO")
+
+	return e.writeLines(lines...)
+}
+
+func (e *icsTaskExporter) End() error {
+	return e.writeLines("END:VCALENDAR")
+}
+
+// writeLines writes folded content lines terminated by CRLF
+func (e *icsTaskExporter) writeLines(lines ...string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// icsStatus maps a task status to the VTODO STATUS property
+func icsStatus(status domain.TaskStatus) string {
+	switch status {
+	case domain.TaskStatusInProgress:
+		return "IN-PROCESS"
+	case domain.TaskStatusDone:
+		return "COMPLETED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// escapeICSText escapes a TEXT property value per RFC 5545 section 3.3.11
+func escapeICSText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets without breaking UTF-8 sequences
+func foldICSLine(line string) string {
+	if len(line) <= icsMaxLineBytes {
+		return line
+	}
+
+	var b strings.Builder
+	limit := icsMaxLineBytes
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = icsMaxLineBytes - 1
+	}
+	b.WriteString(line)
+
+	return b.String()
+}
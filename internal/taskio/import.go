@@ -0,0 +1,276 @@
+package taskio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/vedologic/task-manager/internal/domain"
+	"github.com/vedologic/task-manager/internal/dto"
+)
+
+// MaxImportRows limits the number of rows accepted in a single import
+const MaxImportRows = 10000
+
+// importFields lists the task fields that can be mapped from an import file
+var importFields = []string{"title", "description", "status", "due_date"}
+
+// errEmptyImport is returned for an import file without any task rows
+var errEmptyImport = errors.New("import file is empty")
+
+// importDateLayouts are the accepted due_date formats
+var importDateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// ResolveImportFormat picks the import format from the query parameter or the Content-Type header
+func ResolveImportFormat(format, contentType string) (string, error) {
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch mediaType {
+		case "text/csv":
+			format = FormatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			format = FormatNDJSON
+		}
+	}
+
+	switch format {
+	case FormatCSV, FormatNDJSON:
+		return format, nil
+	case "":
+		return "", errors.New("import format is required (csv or ndjson)")
+	default:
+		return "", fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+// ParseColumnMapping parses "field:column" pairs, e.g. "title:Name,status:State"
+func ParseColumnMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		mapping[field] = field
+	}
+
+	if raw == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid column mapping: %q", pair)
+		}
+		if _, known := mapping[field]; !known {
+			return nil, fmt.Errorf("unknown task field in column mapping: %s", field)
+		}
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+// ParseImport decodes an import file into rows, applying the column mapping
+// and validating each row against the binding rules of CreateTaskRequest.
+// A file without any task rows, such as a CSV file with only a header, is an error.
+func ParseImport(r io.Reader, format string, mapping map[string]string) ([]dto.TaskImportRow, error) {
+	var rows []dto.TaskImportRow
+	var err error
+
+	switch format {
+	case FormatCSV:
+		rows, err = parseCSVImport(r, mapping)
+	case FormatNDJSON:
+		rows, err = parseNDJSONImport(r, mapping)
+	default:
+		err = fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errEmptyImport
+	}
+
+	return rows, nil
+}
+
+// parseCSVImport reads a CSV file with a header row
+func parseCSVImport(r io.Reader, mapping map[string]string) ([]dto.TaskImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errEmptyImport
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columnIndex := make(map[string]int, len(header))
+	for i, name := range header {
+		columnIndex[strings.TrimSpace(name)] = i
+	}
+
+	for _, field := range []string{"title", "status"} {
+		if _, ok := columnIndex[mapping[field]]; !ok {
+			return nil, fmt.Errorf("missing required column %q for field %s", mapping[field], field)
+		}
+	}
+
+	var rows []dto.TaskImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// FieldPos is only valid for a record that was read, which includes one with the wrong number
+		// of fields; other errors carry their line
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to parse CSV on line %d: %w", parseErr.StartLine, parseErr.Err)
+			}
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("import file exceeds the limit of %d rows", MaxImportRows)
+		}
+
+		row := dto.TaskImportRow{Line: line}
+		if err != nil {
+			row.Errors = []string{"wrong number of fields"}
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(field string) string {
+			if i, ok := columnIndex[mapping[field]]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row.Task = dto.CreateTaskRequest{
+			Title:       value("title"),
+			Description: value("description"),
+			Status:      domain.TaskStatus(value("status")),
+		}
+		row.Task.DueDate, row.Errors = parseImportDueDate(value("due_date"))
+		row.Errors = append(row.Errors, validateImportRow(&row.Task)...)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseNDJSONImport reads one JSON object per line
+func parseNDJSONImport(r io.Reader, mapping map[string]string) ([]dto.TaskImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var rows []dto.TaskImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("import file exceeds the limit of %d rows", MaxImportRows)
+		}
+
+		row := dto.TaskImportRow{Line: line}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			row.Errors = []string{"invalid JSON object"}
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(field string) string {
+			switch v := object[mapping[field]].(type) {
+			case nil:
+				return ""
+			case string:
+				return strings.TrimSpace(v)
+			default:
+				return fmt.Sprint(v)
+			}
+		}
+
+		row.Task = dto.CreateTaskRequest{
+			Title:       value("title"),
+			Description: value("description"),
+			Status:      domain.TaskStatus(value("status")),
+		}
+		row.Task.DueDate, row.Errors = parseImportDueDate(value("due_date"))
+		row.Errors = append(row.Errors, validateImportRow(&row.Task)...)
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+
+	return rows, nil
+}
+
+// parseImportDueDate parses an optional due date in RFC 3339 or YYYY-MM-DD form
+func parseImportDueDate(value string) (*time.Time, []string) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, []string{"due_date must be an RFC 3339 timestamp or YYYY-MM-DD date"}
+}
+
+// validateImportRow applies the binding rules declared on CreateTaskRequest
+func validateImportRow(task *dto.CreateTaskRequest) []string {
+	err := binding.Validator.ValidateStruct(task)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		field := strings.ToLower(fieldErr.Field())
+		switch fieldErr.Tag() {
+		case "required":
+			messages = append(messages, fmt.Sprintf("%s is required", field))
+		case "min", "max":
+			messages = append(messages, fmt.Sprintf("%s must satisfy %s=%s", field, fieldErr.Tag(), fieldErr.Param()))
+		default:
+			messages = append(messages, fmt.Sprintf("%s failed on the '%s' rule", field, fieldErr.Tag()))
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,218 @@
+package taskio
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSVImportMalformed(t *testing.T) {
+	mapping, err := ParseColumnMapping("")
+	if err != nil {
+		t.Fatalf("ParseColumnMapping: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+		rows    int
+	}{
+		{
+			name:    "bare quote in field",
+			input:   "title,status\nok,pending\n\"a\"b,pending\n",
+			wantErr: "failed to parse CSV on line 3",
+		},
+		{
+			name:  "wrong number of fields is reported per row",
+			input: "title,status\nok,pending,extra\nb,pending\n",
+			rows:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseImport(strings.NewReader(tt.input), FormatCSV, mapping)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rows) != tt.rows {
+				t.Fatalf("got %d rows, want %d", len(rows), tt.rows)
+			}
+			if rows[0].Line != 2 || len(rows[0].Errors) == 0 {
+				t.Errorf("first row = %+v, want a field count error on line 2", rows[0])
+			}
+		})
+	}
+}
+
+func TestParseImportCSV(t *testing.T) {
+	mapping, _ := ParseColumnMapping("")
+	input := "title, status, description, due_date\n" +
+		"Write report,pending,Quarterly numbers,2024-05-01\n" +
+		"Ship release,done,,2024-05-02T15:04:05Z\n" +
+		",pending,,tomorrow\n"
+
+	rows, err := ParseImport(strings.NewReader(input), FormatCSV, mapping)
+	if err != nil {
+		t.Fatalf("ParseImport() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+
+	first := rows[0]
+	if first.Line != 2 || len(first.Errors) != 0 {
+		t.Errorf("first row = %+v, want a valid row on line 2", first)
+	}
+	if first.Task.Title != "Write report" || first.Task.Description != "Quarterly numbers" || first.Task.Status != "pending" {
+		t.Errorf("first task = %+v, want the values of line 2", first.Task)
+	}
+	if first.Task.DueDate == nil || !first.Task.DueDate.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first due date = %v, want 2024-05-01", first.Task.DueDate)
+	}
+
+	if due := rows[1].Task.DueDate; due == nil || !due.Equal(time.Date(2024, 5, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("second due date = %v, want 2024-05-02T15:04:05Z", due)
+	}
+
+	last := rows[2]
+	if last.Line != 4 {
+		t.Errorf("last row line = %d, want 4", last.Line)
+	}
+	if !containsError(last.Errors, "title is required") || !containsError(last.Errors, "due_date must be") {
+		t.Errorf("last row errors = %q, want the missing title and the bad due date", last.Errors)
+	}
+}
+
+func TestParseImportNDJSON(t *testing.T) {
+	mapping, _ := ParseColumnMapping("")
+	input := `{"title":"Write report","status":"pending","due_date":"2024-05-01"}` + "\n" +
+		"\n" +
+		`{"title":"Ship release","status":"done","description":42}` + "\n" +
+		`{"title":` + "\n"
+
+	rows, err := ParseImport(strings.NewReader(input), FormatNDJSON, mapping)
+	if err != nil {
+		t.Fatalf("ParseImport() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3 (blank lines skipped)", len(rows))
+	}
+
+	if rows[0].Line != 1 || rows[0].Task.Title != "Write report" || rows[0].Task.DueDate == nil || len(rows[0].Errors) != 0 {
+		t.Errorf("first row = %+v, want a valid row on line 1", rows[0])
+	}
+	if rows[1].Line != 3 || rows[1].Task.Description != "42" {
+		t.Errorf("second row = %+v, want line 3 with the number as description", rows[1])
+	}
+	if rows[2].Line != 4 || !containsError(rows[2].Errors, "invalid JSON object") {
+		t.Errorf("third row = %+v, want an invalid JSON error on line 4", rows[2])
+	}
+}
+
+func TestParseImportColumnMapping(t *testing.T) {
+	mapping, err := ParseColumnMapping("title: Name , status:State,due_date:Due")
+	if err != nil {
+		t.Fatalf("ParseColumnMapping() error = %v", err)
+	}
+
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{FormatCSV, "Name,State,Due,description\nWrite report,pending,2024-05-01,Numbers\n"},
+		{FormatNDJSON, `{"Name":"Write report","State":"pending","Due":"2024-05-01","description":"Numbers"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			rows, err := ParseImport(strings.NewReader(tt.input), tt.format, mapping)
+			if err != nil {
+				t.Fatalf("ParseImport() error = %v", err)
+			}
+			task := rows[0].Task
+			if task.Title != "Write report" || task.Status != "pending" || task.Description != "Numbers" || task.DueDate == nil {
+				t.Errorf("task = %+v, want the mapped columns", task)
+			}
+		})
+	}
+
+	_, err = ParseImport(strings.NewReader("title,status\nWrite report,pending\n"), FormatCSV, mapping)
+	if err == nil || !strings.Contains(err.Error(), `missing required column "Name" for field title`) {
+		t.Errorf("ParseImport() error = %v, want the mapped column reported missing", err)
+	}
+}
+
+func TestParseColumnMappingErrors(t *testing.T) {
+	for _, raw := range []string{"title", "title:", "owner:Name"} {
+		if _, err := ParseColumnMapping(raw); err == nil {
+			t.Errorf("ParseColumnMapping(%q) error = nil, want an error", raw)
+		}
+	}
+}
+
+func TestParseImportEmpty(t *testing.T) {
+	mapping, _ := ParseColumnMapping("")
+
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"empty csv", FormatCSV, ""},
+		{"header-only csv", FormatCSV, "title,status\n"},
+		{"empty ndjson", FormatNDJSON, ""},
+		{"blank ndjson", FormatNDJSON, "\n  \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseImport(strings.NewReader(tt.input), tt.format, mapping)
+			if err == nil || err.Error() != "import file is empty" {
+				t.Errorf("ParseImport() = %d rows, error %v, want %q", len(rows), err, "import file is empty")
+			}
+		})
+	}
+}
+
+func TestParseImportRowLimit(t *testing.T) {
+	mapping, _ := ParseColumnMapping("")
+
+	csvInput := func(rows int) string {
+		return "title,status\n" + strings.Repeat("Task,pending\n", rows)
+	}
+	ndjsonInput := func(rows int) string {
+		return strings.Repeat(`{"title":"Task","status":"pending"}`+"\n", rows)
+	}
+
+	for format, input := range map[string]func(int) string{FormatCSV: csvInput, FormatNDJSON: ndjsonInput} {
+		t.Run(format, func(t *testing.T) {
+			rows, err := ParseImport(strings.NewReader(input(MaxImportRows)), format, mapping)
+			if err != nil || len(rows) != MaxImportRows {
+				t.Fatalf("at the limit: %d rows, error %v, want %d rows", len(rows), err, MaxImportRows)
+			}
+
+			_, err = ParseImport(strings.NewReader(input(MaxImportRows+1)), format, mapping)
+			if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+				t.Errorf("over the limit: error = %v, want the row limit reported", err)
+			}
+		})
+	}
+}
+
+// containsError reports whether one of errs contains substr
+func containsError(errs []string, substr string) bool {
+	for _, err := range errs {
+		if strings.Contains(err, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_user_id_due_date;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_date;
@@ -0,0 +1,5 @@
+-- Add optional due date to tasks
+-- Idempotent: Uses IF NOT EXISTS to allow safe re-runs
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_due_date ON tasks (user_id, due_date) WHERE due_date IS NOT NULL;
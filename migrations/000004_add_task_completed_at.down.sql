@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_user_id_completed_at;
+DROP INDEX IF EXISTS idx_tasks_user_id_created_at;
+DROP INDEX IF EXISTS idx_tasks_user_id_status;
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_at;
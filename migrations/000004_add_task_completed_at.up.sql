@@ -0,0 +1,10 @@
+-- Track when tasks were completed and index the columns used by task statistics
+-- Idempotent: Uses IF NOT EXISTS to allow safe re-runs
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+
+-- Backfill existing completed tasks with their last modification time
+UPDATE tasks SET completed_at = updated_at WHERE status = 'done' AND completed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_status ON tasks (user_id, status);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_created_at ON tasks (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_completed_at ON tasks (user_id, completed_at) WHERE completed_at IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Create login_throttles table tracking failed logins per account and per client IP
+-- Idempotent: Uses IF NOT EXISTS to allow safe re-runs
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key_type VARCHAR(10) NOT NULL CHECK (key_type IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (key_type, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles (last_failure_at);
@@ -0,0 +1,10 @@
+-- Create token buckets for rate limiting shared across replicas
+-- Idempotent: Uses IF NOT EXISTS to allow safe re-runs
+-- Buckets are short-lived and rebuilt from scratch after a crash, so the table skips the WAL
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
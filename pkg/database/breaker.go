@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the database while the circuit breaker is open
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe through after the cooldown
+	BreakerHalfOpen
+	// BreakerOpen fails every call until the cooldown has passed
+	BreakerOpen
+)
+
+// String returns the state name used in logs
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker opens after consecutive connection failures so callers fail fast instead of
+// waiting on an unreachable database. After the cooldown one probe is let through: success closes
+// the breaker, another connection failure opens it again. A nil CircuitBreaker is always closed.
+type CircuitBreaker struct {
+	threshold     int
+	cooldown      time.Duration
+	onStateChange func(BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive connection failures
+// and stays open for cooldown; it returns nil, a breaker that never opens, if threshold is not positive
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow returns ErrCircuitOpen if the call must fail fast
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of a call let through by Allow
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.probing
+	b.probing = false
+
+	switch {
+	case Classify(err) == ClassConnection:
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			b.openedAt = time.Now()
+			b.setState(BreakerOpen)
+		}
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		// A cancelled call says nothing about the database; a cancelled probe lets the next call probe
+	default:
+		// The database answered, even if with an error
+		b.failures = 0
+		if wasProbe || b.state == BreakerHalfOpen {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState changes the state and notifies the listener; callers hold mu
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
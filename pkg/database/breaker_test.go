@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	errConnection = &pq.Error{Code: "08006"}
+	errConstraint = &pq.Error{Code: "23505"}
+)
+
+// expireCooldown makes an open breaker ready to let a probe through
+func expireCooldown(b *CircuitBreaker) {
+	b.mu.Lock()
+	b.openedAt = time.Now().Add(-b.cooldown)
+	b.mu.Unlock()
+}
+
+// openBreaker records connection failures until the breaker opens
+func openBreaker(t *testing.T, b *CircuitBreaker) {
+	t.Helper()
+	for range b.threshold {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow before opening = %v", err)
+		}
+		b.Record(errConnection)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("state = %s after %d connection failures, want open", b.State(), b.threshold)
+	}
+}
+
+func TestNewCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker(0, time.Second)
+	if b != nil {
+		t.Fatalf("NewCircuitBreaker(0) = %v, want nil", b)
+	}
+	for range 10 {
+		b.Record(errConnection)
+	}
+	if err := b.Allow(); err != nil || b.State() != BreakerClosed {
+		t.Errorf("nil breaker: Allow = %v, state = %s", err, b.State())
+	}
+}
+
+func TestCircuitBreakerOpensOnConsecutiveConnectionFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []error
+		want     BreakerState
+	}{
+		{"below threshold", []error{errConnection, errConnection}, BreakerClosed},
+		{"at threshold", []error{errConnection, errConnection, errConnection}, BreakerOpen},
+		{"success resets the count", []error{errConnection, errConnection, nil, errConnection, errConnection}, BreakerClosed},
+		{"constraint errors reset the count", []error{errConnection, errConnection, errConstraint, errConnection}, BreakerClosed},
+		{"constraint errors never open", []error{errConstraint, errConstraint, errConstraint, errConstraint}, BreakerClosed},
+		{"cancellation is neutral", []error{errConnection, context.Canceled, errConnection, errConnection}, BreakerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(3, time.Minute)
+			for _, err := range tt.outcomes {
+				if b.Allow() != nil {
+					break
+				}
+				b.Record(err)
+			}
+			if got := b.State(); got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe error
+		want  BreakerState
+	}{
+		{"successful probe closes", nil, BreakerClosed},
+		{"answered probe closes", errConstraint, BreakerClosed},
+		{"failed probe reopens", errConnection, BreakerOpen},
+		{"cancelled probe stays half-open", context.Canceled, BreakerHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []BreakerState
+			b := NewCircuitBreaker(2, time.Minute)
+			b.onStateChange = func(state BreakerState) { changes = append(changes, state) }
+			openBreaker(t, b)
+
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow during cooldown = %v, want ErrCircuitOpen", err)
+			}
+
+			expireCooldown(b)
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow after cooldown = %v, want the probe to pass", err)
+			}
+			if b.State() != BreakerHalfOpen {
+				t.Fatalf("state = %s, want half-open", b.State())
+			}
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow while probing = %v, want ErrCircuitOpen", err)
+			}
+
+			b.Record(tt.probe)
+			if got := b.State(); got != tt.want {
+				t.Fatalf("state = %s, want %s", got, tt.want)
+			}
+			if changes[0] != BreakerOpen || changes[1] != BreakerHalfOpen {
+				t.Errorf("state changes = %v, want open then half-open first", changes)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCancelledProbeLetsNextCallProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute)
+	openBreaker(t, b)
+	expireCooldown(b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow after cooldown = %v", err)
+	}
+	b.Record(context.DeadlineExceeded)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow after a cancelled probe = %v, want another probe", err)
+	}
+	b.Record(nil)
+	if b.State() != BreakerClosed {
+		t.Errorf("state = %s, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerStateString(t *testing.T) {
+	for state, want := range map[BreakerState]string{BreakerClosed: "closed", BreakerHalfOpen: "half-open", BreakerOpen: "open"} {
+		if got := state.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", state, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// credentials are the user and password connections log in with
+type credentials struct {
+	user     string
+	password string
+}
+
+// credentialsConnector opens the connections of the primary pool with the current credentials, so
+// rotated credentials apply without replacing the pool the repositories share
+type credentialsConnector struct {
+	cfg         Config
+	credentials atomic.Pointer[credentials]
+}
+
+// newCredentialsConnector creates a connector logging in with the credentials in cfg
+func newCredentialsConnector(cfg Config) *credentialsConnector {
+	c := &credentialsConnector{cfg: cfg}
+	c.credentials.Store(&credentials{user: cfg.User, password: cfg.Password})
+	return c
+}
+
+// Connect opens a connection with the current credentials
+func (c *credentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connect(ctx, c.credentials.Load())
+}
+
+// connect opens a connection with the given credentials
+func (c *credentialsConnector) connect(ctx context.Context, creds *credentials) (driver.Conn, error) {
+	cfg := c.cfg
+	cfg.User, cfg.Password = creds.user, creds.password
+
+	connector, err := pq.NewConnector(cfg.URL())
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the pq driver, carrying the connector so UpdateCredentials can find it
+func (c *credentialsConnector) Driver() driver.Driver {
+	return credentialsDriver{connector: c}
+}
+
+// credentialsDriver is the pq driver of a pool opened through a credentialsConnector
+type credentialsDriver struct {
+	pq.Driver
+	connector *credentialsConnector
+}
+
+// UpdateCredentials makes a pool opened by NewPostgresDB or WaitForPostgresDB log in with user and
+// password from now on. The new credentials are tried on a fresh connection first; if that fails the
+// pool keeps the current ones. Idle connections are closed so they reconnect, and connections in use
+// are replaced once they reach the connection lifetime.
+func UpdateCredentials(ctx context.Context, db *sqlx.DB, user, password string) error {
+	d, ok := db.Driver().(credentialsDriver)
+	if !ok {
+		return errors.New("database pool does not support changing credentials")
+	}
+
+	next := &credentials{user: user, password: password}
+	conn, err := d.connector.connect(ctx, next)
+	if err != nil {
+		return fmt.Errorf("failed to connect with the new credentials: %w", err)
+	}
+	conn.Close()
+
+	d.connector.credentials.Store(next)
+
+	// Drop the idle connections, which logged in with the previous credentials
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(d.connector.cfg.MaxIdleConns)
+
+	return nil
+}
@@ -1,45 +1,152 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io/fs"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// MigrationsDir is the directory new migration files are written to, relative to the working directory
+const MigrationsDir = "migrations"
+
+// migrationLockID is the pg_advisory_lock key held while migrating. It must differ from the key
+// golang-migrate takes internally during Up, which is derived from the database name.
+const migrationLockID int64 = 0x7461736b6d6967 // "taskmig"
+
+var (
+	// ErrSchemaAhead is returned when the database has migrations this binary does not know about,
+	// usually because a newer release has already migrated it
+	ErrSchemaAhead = errors.New("database schema is newer than this binary")
+	// ErrSchemaBehind is returned when migrations are pending and automatic migration is disabled
+	ErrSchemaBehind = errors.New("database schema is older than this binary")
+	// ErrSchemaDirty is returned when the last migration failed part-way
+	ErrSchemaDirty = errors.New("database schema is dirty")
+)
+
 type MigrationManager struct {
-	dbURL string
-	log   *zap.Logger
+	dbURL  atomic.Pointer[string]
+	source fs.FS
+	log    *zap.Logger
+}
+
+// NewMigrationManager creates a new migration manager applying the migration files in source
+func NewMigrationManager(dbURL string, source fs.FS, log *zap.Logger) *MigrationManager {
+	m := &MigrationManager{
+		source: source,
+		log:    log,
+	}
+	m.dbURL.Store(&dbURL)
+	return m
+}
+
+// SetURL changes the connection URL, e.g. after the database credentials were rotated
+func (m *MigrationManager) SetURL(dbURL string) {
+	m.dbURL.Store(&dbURL)
+}
+
+// newMigrator creates a migrator reading the embedded migrations; callers must close it
+func (m *MigrationManager) newMigrator() (*migrate.Migrate, error) {
+	sourceDriver, err := iofs.New(m.source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrator, err := migrate.NewWithSourceInstance("iofs", sourceDriver, *m.dbURL.Load())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return migrator, nil
+}
+
+// LatestVersion returns the highest migration version shipped with the binary
+func (m *MigrationManager) LatestVersion() (uint, error) {
+	return LatestMigrationVersion(m.source)
+}
+
+// CheckVersion verifies the applied schema version matches the binary without migrating. It fails
+// when the schema is dirty, ahead of the binary, or has pending migrations.
+func (m *MigrationManager) CheckVersion() error {
+	latest, err := m.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.GetMigrationStatus()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to get migration version: %w", err)
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("%w at version %d", ErrSchemaDirty, version)
+	case version > latest:
+		return fmt.Errorf("%w: schema version %d, latest known migration %d", ErrSchemaAhead, version, latest)
+	case version < latest:
+		return fmt.Errorf("%w: schema version %d, latest migration %d", ErrSchemaBehind, version, latest)
+	}
+
+	m.log.Info("Schema version matches the binary", zap.Uint("version", version))
+	return nil
 }
 
-// NewMigrationManager creates a new migration manager instance
-func NewMigrationManager(dbURL string, log *zap.Logger) *MigrationManager {
-	return &MigrationManager{
-		dbURL: dbURL,
-		log:   log,
+// lock takes the migration advisory lock on a dedicated connection, waiting at most timeout for
+// another replica to finish migrating, and returns a function releasing it
+func (m *MigrationManager) lock(ctx context.Context, db *sqlx.DB, timeout time.Duration) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection for migration lock: %w", err)
 	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	m.log.Info("Waiting for migration lock...")
+	if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	m.log.Info("Migration lock acquired")
+
+	return func() {
+		// The lock is tied to the session, so closing the connection would also release it; unlock
+		// explicitly because the pool may keep the connection open
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.log.Warn("Failed to release migration lock", zap.Error(err))
+		}
+		conn.Close()
+	}, nil
 }
 
-// RunMigrationsIfNeeded runs pending migrations if the schema doesn't exist or is incomplete
-func (m *MigrationManager) RunMigrationsIfNeeded() error {
+// RunMigrationsIfNeeded applies pending migrations while holding the migration advisory lock, so
+// replicas starting together migrate one at a time. It refuses to run when the schema is ahead of the binary.
+func (m *MigrationManager) RunMigrationsIfNeeded(ctx context.Context, db *sqlx.DB, lockTimeout time.Duration) error {
 	m.log.Info("Starting migration check...")
 
-	// Get the absolute path to migrations directory
-	migrationsPath, err := filepath.Abs("migrations")
+	unlock, err := m.lock(ctx, db, lockTimeout)
 	if err != nil {
-		return fmt.Errorf("failed to get migrations path: %w", err)
+		return err
 	}
+	defer unlock()
 
-	migrationSourceURL := fmt.Sprintf("file://%s", migrationsPath)
-	m.log.Debug("Migration source URL", zap.String("path", migrationSourceURL))
+	latest, err := m.LatestVersion()
+	if err != nil {
+		return err
+	}
 
-	migrator, err := migrate.New(migrationSourceURL, m.dbURL)
+	migrator, err := m.newMigrator()
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 	defer migrator.Close()
 
@@ -52,7 +159,11 @@ func (m *MigrationManager) RunMigrationsIfNeeded() error {
 			return fmt.Errorf("failed to get migration version: %w", err)
 		}
 	} else {
-		m.log.Info("Current migration version", zap.Uint("version", version), zap.Bool("dirty", dirty))
+		m.log.Info("Current migration version", zap.Uint("version", version), zap.Bool("dirty", dirty), zap.Uint("latest", latest))
+	}
+
+	if version > latest {
+		return fmt.Errorf("%w: schema version %d, latest known migration %d", ErrSchemaAhead, version, latest)
 	}
 
 	// Run pending migrations
@@ -72,49 +183,59 @@ func (m *MigrationManager) RunMigrationsIfNeeded() error {
 
 // GetMigrationStatus returns the current migration status
 func (m *MigrationManager) GetMigrationStatus() (uint, bool, error) {
-	migrationsPath, err := filepath.Abs("migrations")
+	migrator, err := m.newMigrator()
 	if err != nil {
-		return 0, false, fmt.Errorf("failed to get migrations path: %w", err)
+		return 0, false, err
 	}
+	defer migrator.Close()
 
-	migrationSourceURL := fmt.Sprintf("file://%s", migrationsPath)
-	migrator, err := migrate.New(migrationSourceURL, m.dbURL)
+	return migrator.Version()
+}
+
+// ReadMigrationStatus reads the applied migration version from the schema_migrations table over an
+// open pool, without creating a migrator or a connection of its own. It returns migrate.ErrNilVersion
+// when no migration has been applied.
+func ReadMigrationStatus(ctx context.Context, db sqlx.QueryerContext) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowxContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && version < 0) {
+		return 0, false, migrate.ErrNilVersion
+	}
 	if err != nil {
-		return 0, false, fmt.Errorf("failed to create migrator: %w", err)
+		return 0, false, fmt.Errorf("failed to read schema_migrations: %w", err)
 	}
-	defer migrator.Close()
 
-	return migrator.Version()
+	return uint(version), dirty, nil
 }
 
 // DownMigrations rollback all migrations
 func (m *MigrationManager) DownMigrations() error {
-	migrationsPath, err := filepath.Abs("migrations")
+	migrator, err := m.newMigrator()
 	if err != nil {
-		return fmt.Errorf("failed to get migrations path: %w", err)
+		return err
 	}
+	defer migrator.Close()
 
-	migrationSourceURL := fmt.Sprintf("file://%s", migrationsPath)
-	migrator, err := migrate.New(migrationSourceURL, m.dbURL)
+	return migrator.Down()
+}
+
+// StepDown rolls back the last n applied migrations
+func (m *MigrationManager) StepDown(n int) error {
+	migrator, err := m.newMigrator()
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 	defer migrator.Close()
 
-	return migrator.Down()
+	return migrator.Steps(-n)
 }
 
 // ForceVersion sets the migration version without running migrations
 func (m *MigrationManager) ForceVersion(version int) error {
-	migrationsPath, err := filepath.Abs("migrations")
-	if err != nil {
-		return fmt.Errorf("failed to get migrations path: %w", err)
-	}
-
-	migrationSourceURL := fmt.Sprintf("file://%s", migrationsPath)
-	migrator, err := migrate.New(migrationSourceURL, m.dbURL)
+	migrator, err := m.newMigrator()
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 	defer migrator.Close()
 
@@ -149,6 +270,15 @@ func (m *MigrationManager) VerifySchema(db *sqlx.DB, log *zap.Logger) error {
 		{name: "users_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'users'`},
 		{name: "tasks_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'tasks'`},
 		{name: "task_status_enum", query: `SELECT COUNT(*) FROM pg_type WHERE typname = 'task_status'`},
+		{name: "audit_logs_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'audit_logs'`},
+		{name: "saved_views_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'saved_views'`},
+		{name: "personal_access_tokens_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'personal_access_tokens'`},
+		{name: "user_tokens_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'user_tokens'`},
+		{name: "login_throttles_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'login_throttles'`},
+		{name: "recovery_codes_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'recovery_codes'`},
+		{name: "user_identities_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'user_identities'`},
+		{name: "rate_limit_buckets_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'rate_limit_buckets'`},
+		{name: "idempotency_keys_table", query: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'idempotency_keys'`},
 	}
 
 	// Verify critical components (blocking)
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// migrationFilePattern matches migration files such as 000003_add_due_date.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_\w+\.(up|down)\.sql$`)
+
+// migrationNamePattern restricts new migration names to lower snake case
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// LatestMigrationVersion returns the highest migration version found in fsys, or 0 if there is none
+func LatestMigrationVersion(fsys fs.FS) (uint, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if version, err := strconv.ParseUint(match[1], 10, 64); err == nil && uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	return latest, nil
+}
+
+// CreateMigration writes an empty up/down migration pair to dir, numbered after the highest existing
+// migration, and returns the paths of the new files
+func CreateMigration(dir, name string) (string, string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q: use lower snake case, e.g. add_task_priority", name)
+	}
+
+	latest, err := LatestMigrationVersion(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := fmt.Sprintf("%06d_%s", latest+1, name)
+	upPath := filepath.Join(dir, prefix+".up.sql")
+	downPath := filepath.Join(dir, prefix+".down.sql")
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{upPath, "-- TODO: describe the change\n-- Idempotent: Uses IF NOT EXISTS to allow safe re-runs\n"},
+		{downPath, "-- Revert " + name + "\n"},
+	}
+	for _, f := range files {
+		// O_EXCL refuses to overwrite a migration written concurrently
+		file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+		_, writeErr := file.WriteString(f.content)
+		closeErr := file.Close()
+		if writeErr != nil {
+			return "", "", fmt.Errorf("failed to write migration file: %w", writeErr)
+		}
+		if closeErr != nil {
+			return "", "", fmt.Errorf("failed to write migration file: %w", closeErr)
+		}
+	}
+
+	return upPath, downPath, nil
+}
@@ -1,11 +1,18 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 type Config struct {
@@ -20,30 +27,130 @@ type Config struct {
 	ConnMaxLifetime time.Duration
 }
 
+// URL returns the connection URL, used for the pool and by golang-migrate
+func (c Config) URL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.DBName,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
 // NewPostgresDB creates a new PostgreSQL database connection with proper configuration
 func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
-	// Build DSN (Data Source Name)
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
-	)
-
-	// Connect to database
-	db, err := sqlx.Connect("postgres", dsn)
+	db, err := openPostgresDB(cfg)
 	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// waitPingTimeout bounds each connection attempt of WaitForPostgresDB
+const waitPingTimeout = 5 * time.Second
+
+// WaitPolicy bounds the wait for a database that does not accept connections yet
+type WaitPolicy struct {
+	// MaxWait is how long to keep retrying; 0 tries once
+	MaxWait time.Duration
+	// InitialDelay is the delay before the first retry; it doubles with every retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+}
+
+// WaitForPostgresDB connects like NewPostgresDB, retrying with exponential backoff while the database
+// cannot be reached, e.g. because it is still starting. Other errors, such as a wrong password, fail
+// immediately. It gives up once policy.MaxWait has passed or ctx is cancelled.
+func WaitForPostgresDB(ctx context.Context, cfg Config, policy WaitPolicy, log *zap.Logger) (*sqlx.DB, error) {
+	db, err := openPostgresDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(policy.MaxWait)
+	delay := policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, waitPingTimeout)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				log.Info("Database is reachable", zap.Int("attempts", attempt))
+			}
+			return db, nil
+		}
+
+		if ctx.Err() != nil {
+			db.Close()
+			return nil, fmt.Errorf("stopped waiting for the database: %w", ctx.Err())
+		}
+		// A connection attempt that timed out is as retryable as a refused one
+		unreachable := Classify(err) == ClassConnection || errors.Is(err, context.DeadlineExceeded)
+		if !unreachable || time.Now().Add(delay).After(deadline) {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn("Database is not reachable yet, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			db.Close()
+			return nil, fmt.Errorf("stopped waiting for the database: %w", ctx.Err())
+		case <-timer.C:
+		}
+
+		delay = min(2*delay, policy.MaxDelay)
+	}
+}
+
+// openPostgresDB opens a configured pool without connecting. Connections log in with the credentials
+// in cfg until UpdateCredentials replaces them.
+func openPostgresDB(cfg Config) (*sqlx.DB, error) {
+	// Reject a malformed configuration now rather than on the first connection
+	if _, err := pq.NewConnector(cfg.URL()); err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	db := sqlx.NewDb(sql.OpenDB(newCredentialsConnector(cfg)), "postgres")
+
 	// Configure connection pool
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	return db, nil
+}
+
+// NewReplicaDB opens a pool for a read replica given as a URL or key=value DSN, sized like the
+// primary. It does not connect, so an unreachable replica does not stop startup; the router only
+// routes reads to it once it passes a health check.
+func NewReplicaDB(dsn string, cfg Config) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read replica: %w", err)
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	return db, nil
 }
 
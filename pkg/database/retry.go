@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// ErrorClass groups database errors by how a caller can recover from them
+type ErrorClass string
+
+const (
+	// ClassNone is the class of a nil error
+	ClassNone ErrorClass = ""
+	// ClassPermanent errors fail the same way when retried, e.g. constraint violations
+	ClassPermanent ErrorClass = "permanent"
+	// ClassTransaction errors (SQLSTATE class 40, e.g. serialization failures and deadlocks) abort the
+	// transaction; running it again usually succeeds
+	ClassTransaction ErrorClass = "transaction"
+	// ClassConnection errors (SQLSTATE class 08, server shutdown, reset or refused connections) mean
+	// the database could not be reached; they also count towards opening the circuit breaker
+	ClassConnection ErrorClass = "connection"
+)
+
+// Classify returns the recovery class of a database error
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassNone
+	}
+	// A cancelled request must not be retried on its behalf
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ClassPermanent
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "40":
+			return ClassTransaction
+		case pqErr.Code.Class() == "08":
+			return ClassConnection
+		case pqErr.Code == "57P01", pqErr.Code == "57P02", pqErr.Code == "57P03":
+			// admin_shutdown, crash_shutdown and cannot_connect_now happen while the server restarts
+			return ClassConnection
+		}
+		return ClassPermanent
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.As(err, &netErr) {
+		return ClassConnection
+	}
+
+	return ClassPermanent
+}
+
+// IsTransient reports whether err is a database error that may succeed when retried
+func IsTransient(err error) bool {
+	class := Classify(err)
+	return class == ClassTransaction || class == ClassConnection || errors.Is(err, ErrCircuitOpen)
+}
+
+// RetryPolicy bounds the retries of a database operation
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling before the first retry; it doubles with every retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling
+	MaxDelay time.Duration
+}
+
+// backoff returns a random delay before retry number attempt (1-based), between zero and an
+// exponentially growing ceiling ("full jitter"), so retrying clients spread out
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// RetryHooks report retries and circuit breaker decisions, e.g. as metrics; nil hooks are skipped
+type RetryHooks struct {
+	// OnRetry is called before an operation is attempted again
+	OnRetry func(operation string, class ErrorClass)
+	// OnRejected is called when the open circuit breaker fails an operation without trying it
+	OnRejected func(operation string)
+	// OnStateChange is called when the circuit breaker changes state
+	OnStateChange func(state BreakerState)
+}
+
+// Retrier retries transient database failures with jittered exponential backoff and fails fast
+// through a circuit breaker while the database is unreachable. A nil Retrier runs operations once.
+type Retrier struct {
+	policy  RetryPolicy
+	breaker *CircuitBreaker
+	hooks   RetryHooks
+	log     *zap.Logger
+}
+
+// NewRetrier creates a retrier; breaker may be nil to never fail fast
+func NewRetrier(policy RetryPolicy, breaker *CircuitBreaker, hooks RetryHooks, log *zap.Logger) *Retrier {
+	if breaker != nil {
+		breaker.onStateChange = func(state BreakerState) {
+			if state == BreakerOpen {
+				log.Error("Database circuit breaker opened, failing database calls fast", zap.Duration("cooldown", breaker.cooldown))
+			} else {
+				log.Info("Database circuit breaker state changed", zap.String("state", state.String()))
+			}
+			if hooks.OnStateChange != nil {
+				hooks.OnStateChange(state)
+			}
+		}
+	}
+
+	return &Retrier{
+		policy:  policy,
+		breaker: breaker,
+		hooks:   hooks,
+		log:     log,
+	}
+}
+
+// Do runs an idempotent operation, retrying transient failures
+func (r *Retrier) Do(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if r == nil {
+		return fn(ctx)
+	}
+	return r.do(ctx, operation, r.policy.MaxAttempts, IsTransient, fn)
+}
+
+// Guard runs a non-idempotent operation once, failing fast while the circuit breaker is open
+func (r *Retrier) Guard(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if r == nil {
+		return fn(ctx)
+	}
+	return r.do(ctx, operation, 1, IsTransient, fn)
+}
+
+// do runs fn up to maxAttempts times while retryable reports its error as worth retrying
+func (r *Retrier) do(ctx context.Context, operation string, maxAttempts int, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		if err := r.breaker.Allow(); err != nil {
+			if r.hooks.OnRejected != nil {
+				r.hooks.OnRejected(operation)
+			}
+			return err
+		}
+
+		err := fn(ctx)
+		r.breaker.Record(err)
+		if err == nil || attempt >= maxAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		class := Classify(err)
+		delay := r.policy.backoff(attempt)
+		r.log.Warn("Retrying database operation after transient error",
+			zap.String("operation", operation),
+			zap.String("class", string(class)),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		if r.hooks.OnRetry != nil {
+			r.hooks.OnRetry(operation, class)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
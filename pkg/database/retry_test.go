@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ClassNone},
+		{"serialization failure", &pq.Error{Code: "40001"}, ClassTransaction},
+		{"deadlock", &pq.Error{Code: "40P01"}, ClassTransaction},
+		{"wrapped deadlock", fmt.Errorf("failed to update task: %w", &pq.Error{Code: "40P01"}), ClassTransaction},
+		{"connection failure", &pq.Error{Code: "08006"}, ClassConnection},
+		{"connection does not exist", &pq.Error{Code: "08003"}, ClassConnection},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, ClassConnection},
+		{"crash shutdown", &pq.Error{Code: "57P02"}, ClassConnection},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, ClassConnection},
+		{"query canceled", &pq.Error{Code: "57014"}, ClassPermanent},
+		{"unique violation", &pq.Error{Code: "23505"}, ClassPermanent},
+		{"foreign key violation", &pq.Error{Code: "23503"}, ClassPermanent},
+		{"syntax error", &pq.Error{Code: "42601"}, ClassPermanent},
+		{"invalid password", &pq.Error{Code: "28P01"}, ClassPermanent},
+		{"bad connection", driver.ErrBadConn, ClassConnection},
+		{"eof", io.EOF, ClassConnection},
+		{"unexpected eof", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), ClassConnection},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, ClassConnection},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), ClassConnection},
+		{"dns failure", &net.DNSError{Err: "no such host", Name: "db"}, ClassConnection},
+		{"context canceled", context.Canceled, ClassPermanent},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), ClassPermanent},
+		{"other", errors.New("boom"), ClassPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&pq.Error{Code: "40001"}, true},
+		{&pq.Error{Code: "08006"}, true},
+		{fmt.Errorf("read: %w", ErrCircuitOpen), true},
+		{&pq.Error{Code: "23505"}, false},
+		{context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{1, 50 * time.Millisecond},
+		{2, 100 * time.Millisecond},
+		{3, 200 * time.Millisecond},
+		{5, 800 * time.Millisecond},
+		{6, time.Second},
+		{20, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			for range 200 {
+				if d := policy.backoff(tt.attempt); d < 0 || d > tt.ceiling {
+					t.Fatalf("backoff(%d) = %s, want between 0 and %s", tt.attempt, d, tt.ceiling)
+				}
+			}
+		})
+	}
+
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Errorf("backoff without delays = %s, want 0", d)
+	}
+}
+
+func TestRetrierDo(t *testing.T) {
+	transient := &pq.Error{Code: "40001"}
+	permanent := &pq.Error{Code: "23505"}
+
+	tests := []struct {
+		name     string
+		errs     []error
+		guard    bool
+		wantErr  error
+		wantRuns int
+	}{
+		{"success", []error{nil}, false, nil, 1},
+		{"retries transient errors", []error{transient, transient, nil}, false, nil, 3},
+		{"gives up after max attempts", []error{transient, transient, transient, transient}, false, transient, 3},
+		{"does not retry permanent errors", []error{permanent, nil}, false, permanent, 1},
+		{"guard runs once", []error{transient, nil}, true, transient, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRetrier(RetryPolicy{MaxAttempts: 3}, nil, RetryHooks{}, zap.NewNop())
+			runs := 0
+			fn := func(ctx context.Context) error {
+				err := tt.errs[runs]
+				runs++
+				return err
+			}
+
+			var err error
+			if tt.guard {
+				err = r.Guard(context.Background(), "test", fn)
+			} else {
+				err = r.Do(context.Background(), "test", fn)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Router sends reads that tolerate replication lag to healthy read replicas and everything else to
+// the primary. The primary is embedded, so calling query methods on the router directly uses it.
+//
+// Reads are kept on the primary for a stickiness window after a session writes, so a client sees
+// its own changes even when the replicas lag behind.
+//
+// Outside a unit of work, reads returned by Reader and Primary are retried on transient failures and
+// writes returned by Writer fail fast while the circuit breaker is open.
+type Router struct {
+	*sqlx.DB
+
+	replicas  []*replica
+	next      atomic.Uint64
+	stickyFor time.Duration
+	retrier   *Retrier
+	log       *zap.Logger
+
+	mu        sync.Mutex
+	lastWrite map[string]time.Time
+}
+
+// replica is a read-only pool and the result of its last health check
+type replica struct {
+	name    string
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// NewRouter creates a router over the primary and replica pools. Replicas start unhealthy and
+// receive reads once CheckReplicas has reached them; with no replicas every query uses the primary.
+// retrier may be nil to run every statement once.
+func NewRouter(primary *sqlx.DB, replicas []*sqlx.DB, stickyFor time.Duration, retrier *Retrier, log *zap.Logger) *Router {
+	r := &Router{
+		DB:        primary,
+		stickyFor: stickyFor,
+		retrier:   retrier,
+		log:       log,
+		lastWrite: make(map[string]time.Time),
+	}
+	for i, db := range replicas {
+		r.replicas = append(r.replicas, &replica{name: replicaName(i), db: db})
+	}
+	return r
+}
+
+// replicaName names replicas in logs and metrics by their position in the configuration
+func replicaName(i int) string {
+	return fmt.Sprintf("replica-%d", i+1)
+}
+
+// Reader returns the connection for a read that may be served by a replica. Inside a unit of work
+// it returns the transaction; otherwise it falls back to the primary when no replica is healthy or
+// the session in ctx wrote within the stickiness window.
+func (r *Router) Reader(ctx context.Context) sqlx.ExtContext {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return &retryingConn{ExtContext: r.reader(ctx), retrier: r.retrier, operation: "read", retry: true}
+}
+
+// ReaderDB returns the pool that Reader would use outside a unit of work, for reads that need their
+// own transaction, such as server-side cursors. Such reads are not retried.
+func (r *Router) ReaderDB(ctx context.Context) *sqlx.DB {
+	return r.reader(ctx)
+}
+
+// reader picks the pool serving a read outside a unit of work
+func (r *Router) reader(ctx context.Context) *sqlx.DB {
+	if len(r.replicas) == 0 || r.isSticky(ctx) {
+		return r.DB
+	}
+
+	// Round-robin over the replicas, skipping unhealthy ones
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.DB
+}
+
+// Primary returns the primary, or the transaction of the unit of work in ctx, for reads that must
+// see the latest writes regardless of the session, such as credential and throttle lookups. Like
+// reads from Reader, they are retried on transient failures outside a unit of work.
+func (r *Router) Primary(ctx context.Context) sqlx.ExtContext {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return &retryingConn{ExtContext: r.DB, retrier: r.retrier, operation: "read", retry: true}
+}
+
+// Writer returns the primary, or the transaction of the unit of work in ctx, and records the write
+// for the session in ctx so its following reads stay on the primary
+func (r *Router) Writer(ctx context.Context) sqlx.ExtContext {
+	r.MarkWrite(ctx)
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return &retryingConn{ExtContext: r.DB, retrier: r.retrier, operation: "write"}
+}
+
+// MarkWrite starts the stickiness window for the session in ctx
+func (r *Router) MarkWrite(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	key, ok := SessionFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	r.lastWrite[key] = time.Now()
+	r.mu.Unlock()
+}
+
+// isSticky reports whether the session in ctx wrote within the stickiness window
+func (r *Router) isSticky(ctx context.Context) bool {
+	key, ok := SessionFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wroteAt, ok := r.lastWrite[key]
+	return ok && time.Since(wroteAt) < r.stickyFor
+}
+
+// CheckReplicas pings every replica, marking those that do not answer within timeout as unhealthy
+// until a later check succeeds, and forgets writes older than the stickiness window
+func (r *Router) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := rep.db.PingContext(pingCtx)
+
+			healthy := err == nil
+			if rep.healthy.Swap(healthy) == healthy {
+				return
+			}
+			if healthy {
+				r.log.Info("Read replica is healthy, routing reads to it", zap.String("replica", rep.name))
+			} else {
+				r.log.Warn("Read replica is unhealthy, routing its reads elsewhere", zap.String("replica", rep.name), zap.Error(err))
+			}
+		}(rep)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	for key, wroteAt := range r.lastWrite {
+		if time.Since(wroteAt) >= r.stickyFor {
+			delete(r.lastWrite, key)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// Replicas returns the replica pools, e.g. for exporting their connection statistics
+func (r *Router) Replicas() map[string]*sqlx.DB {
+	pools := make(map[string]*sqlx.DB, len(r.replicas))
+	for _, rep := range r.replicas {
+		pools[rep.name] = rep.db
+	}
+	return pools
+}
+
+// CloseReplicas closes the replica pools; the primary is closed by its owner
+func (r *Router) CloseReplicas() error {
+	var firstErr error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// retryingConn runs statements through the retrier: retried when retry is set, otherwise only
+// guarded by the circuit breaker because a write may have been applied before it failed
+type retryingConn struct {
+	sqlx.ExtContext
+	retrier   *Retrier
+	operation string
+	retry     bool
+}
+
+// run executes fn through the retrier
+func (c *retryingConn) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if c.retry {
+		return c.retrier.Do(ctx, c.operation, fn)
+	}
+	return c.retrier.Guard(ctx, c.operation, fn)
+}
+
+func (c *retryingConn) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	err = c.run(ctx, func(ctx context.Context) error {
+		rows, err = c.ExtContext.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (c *retryingConn) QueryxContext(ctx context.Context, query string, args ...any) (rows *sqlx.Rows, err error) {
+	err = c.run(ctx, func(ctx context.Context) error {
+		rows, err = c.ExtContext.QueryxContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowxContext checks the error the row defers to Scan, so a failed query can be retried
+func (c *retryingConn) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	var row *sqlx.Row
+	_ = c.run(ctx, func(ctx context.Context) error {
+		row = c.ExtContext.QueryRowxContext(ctx, query, args...)
+		return row.Err()
+	})
+	if row == nil {
+		// The circuit breaker rejected the query before it ran; the row carries the error to Scan
+		return rejectedDB.QueryRowxContext(ctx, query, args...)
+	}
+	return row
+}
+
+// rejectedDB fails every query with ErrCircuitOpen without contacting the database. It produces
+// the *sqlx.Row of a rejected QueryRowxContext, whose error cannot be set from outside sqlx.
+var rejectedDB = sqlx.NewDb(sql.OpenDB(rejectingConnector{}), "postgres")
+
+type rejectingConnector struct{}
+
+func (rejectingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, ErrCircuitOpen
+}
+
+func (rejectingConnector) Driver() driver.Driver {
+	return rejectingDriver{}
+}
+
+type rejectingDriver struct{}
+
+func (rejectingDriver) Open(string) (driver.Conn, error) {
+	return nil, ErrCircuitOpen
+}
+
+func (c *retryingConn) ExecContext(ctx context.Context, query string, args ...any) (result sql.Result, err error) {
+	err = c.run(ctx, func(ctx context.Context) error {
+		result, err = c.ExtContext.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+type sessionKey struct{}
+
+// WithSession tags ctx with the session whose writes make later reads sticky, typically the user ID
+func WithSession(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+// SessionFromContext returns the session set by WithSession
+func SessionFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(sessionKey{}).(string)
+	return key, ok && key != ""
+}
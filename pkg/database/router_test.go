@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// newTestRouter creates a router over a fake primary and fake replicas named replica-1, replica-2, ...
+func newTestRouter(replicaCount int, stickyFor time.Duration) (*Router, []*fakeDB) {
+	primary, _ := newFakeDB("primary")
+
+	var pools []*sqlx.DB
+	var fakes []*fakeDB
+	for i := 0; i < replicaCount; i++ {
+		db, fake := newFakeDB(replicaName(i))
+		pools = append(pools, db)
+		fakes = append(fakes, fake)
+	}
+
+	return NewRouter(primary, pools, stickyFor, nil, zap.NewNop()), fakes
+}
+
+// servedBy returns the name of the database that answered a query through conn
+func servedBy(t *testing.T, ctx context.Context, conn sqlx.QueryerContext) string {
+	t.Helper()
+
+	var name string
+	if err := sqlx.GetContext(ctx, conn, &name, "SELECT name"); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	return name
+}
+
+func TestRouterWithoutReplicasReadsFromPrimary(t *testing.T) {
+	r, _ := newTestRouter(0, time.Minute)
+	ctx := context.Background()
+
+	if got := servedBy(t, ctx, r.Reader(ctx)); got != "primary" {
+		t.Errorf("read served by %s, want primary", got)
+	}
+}
+
+func TestRouterReadsFromHealthyReplicas(t *testing.T) {
+	r, _ := newTestRouter(2, time.Minute)
+	ctx := context.Background()
+
+	// Replicas receive no reads until a health check has reached them
+	if got := servedBy(t, ctx, r.Reader(ctx)); got != "primary" {
+		t.Errorf("read before the first health check served by %s, want primary", got)
+	}
+
+	r.CheckReplicas(ctx, time.Second)
+
+	served := map[string]int{}
+	for i := 0; i < 4; i++ {
+		served[servedBy(t, ctx, r.Reader(ctx))]++
+	}
+	if served["replica-1"] != 2 || served["replica-2"] != 2 {
+		t.Errorf("reads served = %v, want two from each replica", served)
+	}
+
+	if got := servedBy(t, ctx, r.Primary(ctx)); got != "primary" {
+		t.Errorf("primary read served by %s, want primary", got)
+	}
+}
+
+func TestRouterFallsBackWhenReplicasAreUnhealthy(t *testing.T) {
+	r, replicas := newTestRouter(2, time.Minute)
+	ctx := context.Background()
+	r.CheckReplicas(ctx, time.Second)
+
+	replicas[0].setDown(true)
+	r.CheckReplicas(ctx, time.Second)
+
+	for i := 0; i < 3; i++ {
+		if got := servedBy(t, ctx, r.Reader(ctx)); got != "replica-2" {
+			t.Errorf("read served by %s with replica-1 down, want replica-2", got)
+		}
+	}
+
+	replicas[1].setDown(true)
+	r.CheckReplicas(ctx, time.Second)
+
+	if got := servedBy(t, ctx, r.Reader(ctx)); got != "primary" {
+		t.Errorf("read served by %s with every replica down, want primary", got)
+	}
+
+	replicas[0].setDown(false)
+	r.CheckReplicas(ctx, time.Second)
+
+	if got := servedBy(t, ctx, r.Reader(ctx)); got != "replica-1" {
+		t.Errorf("read served by %s after replica-1 recovered, want replica-1", got)
+	}
+}
+
+func TestRouterKeepsWritingSessionOnPrimary(t *testing.T) {
+	stickyFor := time.Minute
+	r, _ := newTestRouter(1, stickyFor)
+	r.CheckReplicas(context.Background(), time.Second)
+
+	writer := WithSession(context.Background(), "7")
+	other := WithSession(context.Background(), "8")
+
+	if _, err := r.Writer(writer).ExecContext(writer, "UPDATE tasks"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	if got := servedBy(t, writer, r.Reader(writer)); got != "primary" {
+		t.Errorf("read after a write served by %s, want primary", got)
+	}
+	if got := servedBy(t, other, r.Reader(other)); got != "replica-1" {
+		t.Errorf("read of another session served by %s, want replica-1", got)
+	}
+	if got := servedBy(t, context.Background(), r.Reader(context.Background())); got != "replica-1" {
+		t.Errorf("read without a session served by %s, want replica-1", got)
+	}
+
+	// Once the stickiness window has passed the session reads from replicas again
+	r.mu.Lock()
+	r.lastWrite["7"] = time.Now().Add(-stickyFor)
+	r.mu.Unlock()
+
+	if got := servedBy(t, writer, r.Reader(writer)); got != "replica-1" {
+		t.Errorf("read after the stickiness window served by %s, want replica-1", got)
+	}
+
+	r.CheckReplicas(context.Background(), time.Second)
+	r.mu.Lock()
+	remembered := len(r.lastWrite)
+	r.mu.Unlock()
+	if remembered != 0 {
+		t.Errorf("%d expired writes are still remembered after a health check", remembered)
+	}
+}
+
+func TestRouterReturnsTransactionOfUnitOfWork(t *testing.T) {
+	r, _ := newTestRouter(1, time.Minute)
+	r.CheckReplicas(context.Background(), time.Second)
+	m := NewTxManager(r.DB, nil)
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		tx := TxFromContext(ctx)
+		for name, conn := range map[string]sqlx.ExtContext{
+			"Reader":  r.Reader(ctx),
+			"Primary": r.Primary(ctx),
+			"Writer":  r.Writer(ctx),
+		} {
+			if conn != tx {
+				t.Errorf("%s returned %T, want the transaction of the unit of work", name, conn)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -10,9 +11,89 @@ import (
 // TxFunc is a function type that operates within a transaction
 type TxFunc func(*sqlx.Tx) error
 
-// WithTransaction executes a function within a database transaction
-// It handles commit/rollback automatically based on the function's return value
-func WithTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) error {
+// txKey carries the transaction of a unit of work in a context
+type txKey struct{}
+
+// txState is the transaction in a context and how many savepoints deep the current call is
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+// TxFromContext returns the transaction started by TxManager.WithinTx, or nil outside one
+func TxFromContext(ctx context.Context) *sqlx.Tx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return nil
+}
+
+// Conn returns the transaction carried by ctx, or db when ctx has none. Repositories run every
+// statement through it so they join the caller's unit of work.
+func Conn(ctx context.Context, db sqlx.ExtContext) sqlx.ExtContext {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return db
+}
+
+// TxManager runs units of work spanning several repositories in one transaction
+type TxManager struct {
+	db      *sqlx.DB
+	retrier *Retrier
+}
+
+// NewTxManager creates a transaction manager on the primary database; retrier may be nil to run
+// every unit of work once
+func NewTxManager(db *sqlx.DB, retrier *Retrier) *TxManager {
+	return &TxManager{db: db, retrier: retrier}
+}
+
+// WithinTx runs fn in a transaction carried by the context passed to fn, committing when fn
+// returns nil and rolling back otherwise. Called within another unit of work, fn runs in a
+// savepoint instead, so its failure only undoes its own changes.
+//
+// An outermost unit of work that fails transiently, e.g. on a serialization failure or a dropped
+// connection, is run again from the start, so fn must not have side effects outside the database.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if TxFromContext(ctx) != nil || m.retrier == nil {
+		return withinTx(ctx, m.db, fn)
+	}
+
+	return m.retrier.do(ctx, "transaction", m.retrier.policy.MaxAttempts, retryableTx, func(ctx context.Context) error {
+		return withinTx(ctx, m.db, fn)
+	})
+}
+
+// commitError marks a failed commit. When the connection dropped during the commit the transaction
+// may have been applied, so it is not run again.
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return fmt.Sprintf("failed to commit transaction: %v", e.err)
+}
+
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// retryableTx reports whether a failed unit of work is safe to run again
+func retryableTx(err error) bool {
+	var commitErr *commitError
+	if errors.As(err, &commitErr) && Classify(err) == ClassConnection {
+		return false
+	}
+	return IsTransient(err)
+}
+
+// withinTx starts a transaction on db, or a savepoint when ctx already carries one
+func withinTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return withinSavepoint(ctx, state, fn)
+	}
+
 	// Begin transaction
 	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -29,7 +110,7 @@ func WithTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) error {
 	}()
 
 	// Execute the function
-	if err := fn(tx); err != nil {
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
 		// Error occurred, rollback transaction
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("failed to rollback transaction: %w, original error: %v", rbErr, err)
@@ -39,8 +120,42 @@ func WithTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) error {
 
 	// No error, commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return &commitError{err: err}
 	}
 
 	return nil
 }
+
+// withinSavepoint runs a nested unit of work in a savepoint of the enclosing transaction. Savepoints
+// are named by depth; sibling calls run one after another, so they can reuse the name.
+func withinSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	state := &txState{tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("unit_of_work_%d", state.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	// A panic is left to the outermost call, which rolls back the whole transaction
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return fmt.Errorf("failed to rollback to savepoint: %w, original error: %v", rbErr, err)
+		}
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// WithTransaction executes a function within a database transaction
+// It handles commit/rollback automatically based on the function's return value.
+// Inside a unit of work started by TxManager, fn joins that transaction through a savepoint.
+func WithTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) error {
+	return withinTx(ctx, db, func(ctx context.Context) error {
+		return fn(TxFromContext(ctx))
+	})
+}
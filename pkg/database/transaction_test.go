@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// fakeDB is a database/sql driver that records the statements it receives instead of running them.
+// Queries answer with a single row holding the name of the database, so tests can tell pools apart.
+type fakeDB struct {
+	name string
+
+	mu         sync.Mutex
+	statements []string
+	// fail returns the error for a statement, or nil to let it succeed
+	fail func(statement string) error
+	// down makes pings fail, as if the server could not be reached
+	down bool
+}
+
+// newFakeDB opens a pool on a fakeDB
+func newFakeDB(name string) (*sqlx.DB, *fakeDB) {
+	f := &fakeDB{name: name}
+	return sqlx.NewDb(sql.OpenDB(f), "postgres"), f
+}
+
+// record logs a statement and returns the error configured for it
+func (f *fakeDB) record(statement string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statements = append(f.statements, statement)
+	if f.fail != nil {
+		return f.fail(statement)
+	}
+	return nil
+}
+
+// log returns the recorded statements and clears them
+func (f *fakeDB) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statements := f.statements
+	f.statements = nil
+	return statements
+}
+
+// setDown makes the database unreachable, or reachable again
+func (f *fakeDB) setDown(down bool) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{db: f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	if c.db.down {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if err := c.db.record("BEGIN"); err != nil {
+		return nil, err
+	}
+	return &fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.db.record(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.db.record(query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{value: c.db.name}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (t *fakeTx) Commit() error {
+	return t.db.record("COMMIT")
+}
+
+func (t *fakeTx) Rollback() error {
+	return t.db.record("ROLLBACK")
+}
+
+// fakeRows is a result set of one row with one column
+type fakeRows struct {
+	value string
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"name"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestWithinTxCommitsAndRollsBack(t *testing.T) {
+	db, fake := newFakeDB("primary")
+	m := NewTxManager(db, nil)
+	errFailed := errors.New("failed")
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if TxFromContext(ctx) == nil {
+			t.Error("unit of work has no transaction in its context")
+		}
+		_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE tasks")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+	if got, want := fake.log(), []string{"BEGIN", "UPDATE tasks", "COMMIT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+
+	err = m.WithinTx(context.Background(), func(ctx context.Context) error {
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("WithinTx() error = %v, want %v", err, errFailed)
+	}
+	if got, want := fake.log(), []string{"BEGIN", "ROLLBACK"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTxNestsInSavepoints(t *testing.T) {
+	db, fake := newFakeDB("primary")
+	m := NewTxManager(db, nil)
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		outer := TxFromContext(ctx)
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			if TxFromContext(ctx) != outer {
+				t.Error("nested unit of work does not share the outer transaction")
+			}
+			return m.WithinTx(ctx, func(ctx context.Context) error {
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT unit_of_work_1",
+		"SAVEPOINT unit_of_work_2",
+		"RELEASE SAVEPOINT unit_of_work_2",
+		"RELEASE SAVEPOINT unit_of_work_1",
+		"COMMIT",
+	}
+	if got := fake.log(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTxRollsBackOnlyTheFailedSavepoint(t *testing.T) {
+	db, fake := newFakeDB("primary")
+	m := NewTxManager(db, nil)
+	errFailed := errors.New("failed")
+
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := m.WithinTx(ctx, func(ctx context.Context) error {
+			_, _ = Conn(ctx, db).ExecContext(ctx, "INSERT audit_logs")
+			return errFailed
+		}); !errors.Is(err, errFailed) {
+			t.Errorf("nested WithinTx() error = %v, want %v", err, errFailed)
+		}
+
+		// The outer unit of work carries on after the failure and commits its own changes
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE tasks")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT unit_of_work_1",
+		"INSERT audit_logs",
+		"ROLLBACK TO SAVEPOINT unit_of_work_1",
+		"SAVEPOINT unit_of_work_1",
+		"UPDATE tasks",
+		"RELEASE SAVEPOINT unit_of_work_1",
+		"COMMIT",
+	}
+	if got := fake.log(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTxRetriesOnlyTheOutermostUnit(t *testing.T) {
+	db, fake := newFakeDB("primary")
+	m := NewTxManager(db, NewRetrier(RetryPolicy{MaxAttempts: 3}, nil, RetryHooks{}, zap.NewNop()))
+	serialization := &pq.Error{Code: "40001"}
+
+	outerRuns, innerRuns := 0, 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		outerRuns++
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			innerRuns++
+			if innerRuns == 1 {
+				return serialization
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+	if outerRuns != 2 || innerRuns != 2 {
+		t.Errorf("outer ran %d times and inner %d times, want 2 each", outerRuns, innerRuns)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT unit_of_work_1",
+		"ROLLBACK TO SAVEPOINT unit_of_work_1",
+		"ROLLBACK",
+		"BEGIN",
+		"SAVEPOINT unit_of_work_1",
+		"RELEASE SAVEPOINT unit_of_work_1",
+		"COMMIT",
+	}
+	if got := fake.log(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTxDoesNotRetryLostCommit(t *testing.T) {
+	db, fake := newFakeDB("primary")
+	m := NewTxManager(db, NewRetrier(RetryPolicy{MaxAttempts: 3}, nil, RetryHooks{}, zap.NewNop()))
+	fake.fail = func(statement string) error {
+		if statement == "COMMIT" {
+			return driver.ErrBadConn
+		}
+		return nil
+	}
+
+	runs := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	if err == nil {
+		t.Fatal("WithinTx() error = nil, want the commit failure")
+	}
+	if runs != 1 {
+		t.Errorf("ran %d times, want 1", runs)
+	}
+}
+
+func TestWithinTxDoesNotRetryPermanentErrors(t *testing.T) {
+	db, _ := newFakeDB("primary")
+	m := NewTxManager(db, NewRetrier(RetryPolicy{MaxAttempts: 3}, nil, RetryHooks{}, zap.NewNop()))
+	unique := &pq.Error{Code: "23505"}
+
+	runs := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return unique
+	})
+	if !errors.Is(err, unique) {
+		t.Fatalf("WithinTx() error = %v, want %v", err, unique)
+	}
+	if runs != 1 {
+		t.Errorf("ran %d times, want 1", runs)
+	}
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger is implemented by *sql.DB and *sqlx.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DatabaseCheck fails when the database does not answer a ping
+func DatabaseCheck(db Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("database ping failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrationStatusFunc reads the applied schema version and whether it is dirty
+type MigrationStatusFunc func(ctx context.Context) (uint, bool, error)
+
+// MigrationCheck fails when the last migration left the schema dirty
+func MigrationCheck(status MigrationStatusFunc) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		version, dirty, err := status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read migration status: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("schema is dirty at version %d", version)
+		}
+		return nil
+	})
+}
+
+// Heartbeat tracks a background worker; the worker calls Beat after every run
+type Heartbeat struct {
+	maxAge time.Duration
+	last   atomic.Int64
+}
+
+// NewHeartbeat creates a heartbeat that goes stale when no beat arrives within maxAge
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{maxAge: maxAge}
+	h.Beat()
+	return h
+}
+
+// Beat records that the worker is alive
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Check fails when the last beat is older than maxAge
+func (h *Heartbeat) Check(ctx context.Context) error {
+	age := time.Since(time.Unix(0, h.last.Load()))
+	if age > h.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusPass     = "pass"
+	StatusFail     = "fail"
+	StatusStarting = "starting"
+)
+
+// Checker probes a single dependency; a nil error means it is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string
+	Status   string
+	Error    string
+	Duration time.Duration
+}
+
+// Report is the outcome of a liveness or readiness probe
+type Report struct {
+	Status string
+	Checks []Result
+}
+
+// Healthy reports whether every check passed
+func (r Report) Healthy() bool {
+	return r.Status == StatusPass
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Health runs the registered liveness and readiness checks
+type Health struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	liveness     []namedChecker
+	readiness    []namedChecker
+	starting     atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// New creates a Health whose checks each get at most timeout to complete
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// AddLivenessCheck registers a check that fails only when the process needs a restart
+func (h *Health) AddLivenessCheck(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, namedChecker{name: name, checker: checker})
+}
+
+// AddReadinessCheck registers a check that fails while the instance cannot serve traffic
+func (h *Health) AddReadinessCheck(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, namedChecker{name: name, checker: checker})
+}
+
+// SetStarting makes readiness report StatusStarting until SetStarted is called, e.g. while the
+// instance waits for its dependencies; liveness checks keep running
+func (h *Health) SetStarting() {
+	h.starting.Store(true)
+}
+
+// SetStarted runs the readiness checks from now on
+func (h *Health) SetStarted() {
+	h.starting.Store(false)
+}
+
+// SetShuttingDown makes readiness fail from now on, so load balancers stop routing to the instance
+// while in-flight requests drain
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness runs the liveness checks
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.liveness
+	h.mu.RUnlock()
+
+	return h.run(ctx, checks)
+}
+
+// Readiness runs the readiness checks; it fails without running them while the instance is
+// starting or once shutdown has begun
+func (h *Health) Readiness(ctx context.Context) Report {
+	if h.shuttingDown.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: []Result{{Name: "shutdown", Status: StatusFail, Error: "server is shutting down"}},
+		}
+	}
+	if h.starting.Load() {
+		return Report{
+			Status: StatusStarting,
+			Checks: []Result{{Name: "startup", Status: StatusFail, Error: "server is starting"}},
+		}
+	}
+
+	h.mu.RLock()
+	checks := h.readiness
+	h.mu.RUnlock()
+
+	return h.run(ctx, checks)
+}
+
+// run executes checks concurrently and reports them in name order
+func (h *Health) run(ctx context.Context, checks []namedChecker) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusPass, Checks: results}
+	for _, r := range results {
+		if r.Status != StatusPass {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runCheck runs one check with the configured timeout; a check that ignores its context still
+// fails once the timeout expires
+func (h *Health) runCheck(ctx context.Context, c namedChecker) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", h.timeout)
+	}
+
+	result := Result{Name: c.name, Status: StatusPass, Duration: time.Since(start)}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/vedologic/task-manager/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// WithContext returns log annotated with the request ID and trace context carried by ctx,
+// so log lines can be matched with the request and its spans
+func WithContext(ctx context.Context, log *zap.Logger) *zap.Logger {
+	fields := make([]zap.Field, 0, 3)
+
+	if id := tracing.RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	if len(fields) == 0 {
+		return log
+	}
+	return log.With(fields...)
+}
@@ -10,6 +10,7 @@ import (
 // Logger wraps zap logger for convenience
 type Logger struct {
 	*zap.Logger
+	level zap.AtomicLevel
 }
 
 // New creates a new logger instance based on the environment
@@ -39,7 +40,23 @@ func New(level string) (*Logger, error) {
 		return nil, fmt.Errorf("failed to build logger: %w", err)
 	}
 
-	return &Logger{logger}, nil
+	return &Logger{Logger: logger, level: zapConfig.Level}, nil
+}
+
+// SetLevel changes the level of the logger and every logger derived from it, e.g. on a
+// configuration reload. The output format chosen at startup is kept.
+func (l *Logger) SetLevel(level string) error {
+	logLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	l.level.SetLevel(logLevel)
+	return nil
+}
+
+// Level returns the current level
+func (l *Logger) Level() string {
+	return l.level.Level().String()
 }
 
 // Sync flushes any buffered log entries
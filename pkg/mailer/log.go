@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// logMailer writes messages to the application log instead of sending them.
+// It is intended for development, where links can be copied from the log.
+type logMailer struct {
+	log *zap.Logger
+}
+
+// NewLogMailer creates a mailer that only logs messages
+func NewLogMailer(log *zap.Logger) Mailer {
+	return &logMailer{log: log}
+}
+
+// Send logs the message
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	m.log.Info("Email (not sent, log mailer)",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email
+type Mailer interface {
+	// Send delivers a single message
+	Send(ctx context.Context, msg Message) error
+}
+
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+type Config struct {
+	Driver   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// New creates the mailer selected by cfg.Driver
+func New(cfg Config, log *zap.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		if cfg.Host == "" || cfg.From == "" {
+			return nil, fmt.Errorf("smtp mailer requires a host and a from address")
+		}
+		return NewSMTPMailer(cfg), nil
+	case DriverLog, "":
+		return NewLogMailer(log), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+	}
+}
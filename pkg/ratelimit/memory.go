@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often full buckets are removed from a memory store
+const memorySweepInterval = time.Minute
+
+// memoryBucket is the state of a single in-memory token bucket
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket will have refilled completely and can be forgotten
+	fullAt time.Time
+}
+
+// memoryStore keeps token buckets in process memory; limits are not shared between replicas
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates a store that keeps buckets in process memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take removes one token from the bucket for key if one is available
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	capacity := float64(limit.Requests)
+	rate := limit.RatePerSecond()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := math.Max(0, now.Sub(bucket.updatedAt).Seconds())
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+	bucket.updatedAt = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	bucket.fullAt = now.Add(secondsToDuration((capacity - bucket.tokens) / rate))
+
+	return NewResult(limit, allowed, bucket.tokens), nil
+}
+
+// sweep forgets buckets that have refilled, since a missing bucket starts full. The caller must hold mu.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
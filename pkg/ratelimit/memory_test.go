@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// rewind moves the last update of a bucket into the past, as if elapsed had passed
+func rewind(s *memoryStore, key string, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buckets[key].updatedAt = s.buckets[key].updatedAt.Add(-elapsed)
+}
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+	store := NewMemoryStore().(*memoryStore)
+
+	for i := range 3 {
+		result, err := store.Take(ctx, "client", limit)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: %+v, %v; want allowed", i+1, result, err)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("request %d: remaining = %d, want %d", i+1, result.Remaining, 2-i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "client", limit)
+	if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > time.Second {
+		t.Fatalf("request over the burst: %+v; want denied with a retry within a second", result)
+	}
+
+	// Another key has its own bucket
+	if result, _ := store.Take(ctx, "other", limit); !result.Allowed {
+		t.Errorf("other key: %+v; want allowed", result)
+	}
+
+	// One token refills every second
+	rewind(store, "client", time.Second)
+	if result, _ := store.Take(ctx, "client", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after refilling one token: %+v; want allowed with none remaining", result)
+	}
+
+	// The bucket never holds more than its capacity
+	rewind(store, "client", time.Hour)
+	if result, _ := store.Take(ctx, "client", limit); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after a long pause: %+v; want allowed with 2 remaining", result)
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Per: time.Second}
+	store := NewMemoryStore().(*memoryStore)
+
+	store.Take(ctx, "idle", limit)
+	store.Take(ctx, "busy", limit)
+	store.Take(ctx, "busy", limit)
+
+	store.mu.Lock()
+	now := time.Now()
+	store.buckets["idle"].fullAt = now.Add(-time.Second)
+	store.buckets["busy"].fullAt = now.Add(time.Minute)
+	store.lastSweep = now.Add(-memorySweepInterval)
+	store.sweep(now)
+	_, idle := store.buckets["idle"]
+	_, busy := store.buckets["busy"]
+	store.mu.Unlock()
+
+	if idle || !busy {
+		t.Errorf("after sweep: idle kept = %v, busy kept = %v; want only the busy bucket", idle, busy)
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket that holds up to Requests tokens and refills Requests tokens every Per.
+// Each request takes one token, so a client can burst up to Requests and then sustain Requests per Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether the limit is configured
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// RatePerSecond is the number of tokens added to the bucket every second
+func (l Limit) RatePerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// String formats the limit in the form accepted by ParseLimit
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// ParseLimit parses a limit such as "300/1m". An empty string or "0" disables the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<duration>", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// Result describes the outcome of taking a token from a bucket
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request would be allowed; zero when Allowed
+	RetryAfter time.Duration
+}
+
+// NewResult builds the result for a bucket left holding tokens after a request was allowed or denied
+func NewResult(limit Limit, allowed bool, tokens float64) Result {
+	rate := limit.RatePerSecond()
+	tokens = math.Max(0, tokens)
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Requests,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(limit.Requests) - tokens) / rate),
+	}
+
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+// secondsToDuration converts fractional seconds to a non-negative duration
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Store keeps token buckets. Implementations must take tokens atomically so concurrent
+// requests for the same key cannot exceed the limit.
+type Store interface {
+	// Take removes one token from the bucket for key if one is available
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Store drivers selectable by configuration
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
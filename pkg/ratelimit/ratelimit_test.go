@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Limit
+		wantErr bool
+	}{
+		{"300/1m", Limit{Requests: 300, Per: time.Minute}, false},
+		{" 20 / 30s ", Limit{Requests: 20, Per: 30 * time.Second}, false},
+		{"", Limit{}, false},
+		{"0", Limit{}, false},
+		{"300", Limit{}, true},
+		{"0/1m", Limit{}, true},
+		{"-5/1m", Limit{}, true},
+		{"x/1m", Limit{}, true},
+		{"10/0s", Limit{}, true},
+		{"10/minute", Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLimit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitRoundTrip(t *testing.T) {
+	limit := Limit{Requests: 120, Per: time.Minute}
+	got, err := ParseLimit(limit.String())
+	if err != nil || got != limit {
+		t.Errorf("ParseLimit(%q) = %+v, %v; want %+v", limit.String(), got, err, limit)
+	}
+	if !limit.Enabled() || (Limit{}).Enabled() {
+		t.Error("Enabled should hold only for configured limits")
+	}
+	if rate := limit.RatePerSecond(); rate != 2 {
+		t.Errorf("RatePerSecond = %v, want 2", rate)
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	// 10 tokens per 10s refill one token per second
+	limit := Limit{Requests: 10, Per: 10 * time.Second}
+
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{
+			name:    "full after taking one",
+			allowed: true,
+			tokens:  9,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: time.Second},
+		},
+		{
+			name:    "fractional tokens round down",
+			allowed: true,
+			tokens:  2.5,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 2, ResetAfter: 7500 * time.Millisecond},
+		},
+		{
+			name:    "denied with a partial token",
+			allowed: false,
+			tokens:  0.25,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, ResetAfter: 9750 * time.Millisecond, RetryAfter: 750 * time.Millisecond},
+		},
+		{
+			name:    "negative tokens count as empty",
+			allowed: false,
+			tokens:  -1,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, ResetAfter: 10 * time.Second, RetryAfter: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewResult(limit, tt.allowed, tt.tokens); got != tt.want {
+				t.Errorf("NewResult = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package secrets
+
+import "context"
+
+// Provider reads secrets from an external secret store
+type Provider interface {
+	// Secrets returns the values stored in the secret at path, by name
+	Secrets(ctx context.Context, path string) (map[string]string, error)
+}
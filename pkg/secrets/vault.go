@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// vaultRequestTimeout bounds a Vault request when the context has no deadline
+const vaultRequestTimeout = 10 * time.Second
+
+// VaultConfig configures access to a HashiCorp Vault KV secrets engine
+type VaultConfig struct {
+	// Addr is the Vault server address, e.g. http://127.0.0.1:8200
+	Addr  string
+	Token string
+	// Namespace is sent as X-Vault-Namespace when set (Vault Enterprise)
+	Namespace string
+	// Mount is the path the KV engine is mounted at
+	Mount string
+	// KVVersion is the KV engine version, 1 or 2
+	KVVersion int
+}
+
+// vaultProvider reads secrets from a Vault KV engine over its HTTP API
+type vaultProvider struct {
+	cfg    VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider creates a provider reading secrets from a Vault KV engine
+func NewVaultProvider(cfg VaultConfig) Provider {
+	return &vaultProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: vaultRequestTimeout},
+	}
+}
+
+// Secrets reads the latest version of the secret at path
+func (p *vaultProvider) Secrets(ctx context.Context, path string) (map[string]string, error) {
+	endpoint, err := p.endpoint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("secret %s not found in Vault mount %s", path, p.cfg.Mount)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("vault returned %s: %s", resp.Status, vaultErrors(body))
+	}
+
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+
+	// KV version 2 nests the values under data.data, next to the version metadata
+	data := secret.Data
+	if p.cfg.KVVersion == 2 {
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+		}
+		data = versioned.Data
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	if values == nil {
+		// A deleted version of a KV version 2 secret has no data
+		return nil, fmt.Errorf("secret %s in Vault mount %s has no data", path, p.cfg.Mount)
+	}
+
+	secrets := make(map[string]string, len(values))
+	for name, value := range values {
+		switch value := value.(type) {
+		case string:
+			secrets[name] = value
+		case float64, bool:
+			secrets[name] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("secret %s: value of %s is not a string", path, name)
+		}
+	}
+	return secrets, nil
+}
+
+// endpoint returns the URL reading the secret at path
+func (p *vaultProvider) endpoint(path string) (string, error) {
+	base, err := url.Parse(p.cfg.Addr)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return "", fmt.Errorf("invalid Vault address %q", p.cfg.Addr)
+	}
+
+	mount := strings.Trim(p.cfg.Mount, "/")
+	path = strings.Trim(path, "/")
+	if p.cfg.KVVersion == 2 {
+		return base.JoinPath("v1", mount, "data", path).String(), nil
+	}
+	return base.JoinPath("v1", mount, path).String(), nil
+}
+
+// vaultErrors extracts the messages of a Vault error response
+func vaultErrors(body []byte) string {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return "no error details"
+	}
+	return strings.Join(resp.Errors, "; ")
+}
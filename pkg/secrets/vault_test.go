@@ -0,0 +1,155 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestVault serves body with status for GET requests to path carrying the token "test-token",
+// and 403 for any other token
+func newTestVault(t *testing.T, path string, status int, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultProviderReadsSecrets(t *testing.T) {
+	want := map[string]string{"DB_PASSWORD": "s3cret", "DB_PORT": "5433", "AUTH_REQUIRE_VERIFIED_EMAIL": "true"}
+
+	tests := []struct {
+		name      string
+		kvVersion int
+		path      string
+		body      string
+	}{
+		{
+			name:      "kv version 1",
+			kvVersion: 1,
+			path:      "/v1/secret/task-manager",
+			body:      `{"data":{"DB_PASSWORD":"s3cret","DB_PORT":5433,"AUTH_REQUIRE_VERIFIED_EMAIL":true}}`,
+		},
+		{
+			name:      "kv version 2",
+			kvVersion: 2,
+			path:      "/v1/secret/data/task-manager",
+			body:      `{"data":{"data":{"DB_PASSWORD":"s3cret","DB_PORT":5433,"AUTH_REQUIRE_VERIFIED_EMAIL":true},"metadata":{"version":3}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestVault(t, tt.path, http.StatusOK, tt.body)
+			p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "test-token", Mount: "/secret/", KVVersion: tt.kvVersion})
+
+			got, err := p.Secrets(context.Background(), "task-manager")
+			if err != nil {
+				t.Fatalf("Secrets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Secrets() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestVaultProviderSendsNamespace(t *testing.T) {
+	var namespace string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace = r.Header.Get("X-Vault-Namespace")
+		_, _ = w.Write([]byte(`{"data":{"DB_PASSWORD":"s3cret"}}`))
+	}))
+	defer srv.Close()
+
+	p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "test-token", Namespace: "team-a", Mount: "secret", KVVersion: 1})
+	if _, err := p.Secrets(context.Background(), "task-manager"); err != nil {
+		t.Fatalf("Secrets() error = %v", err)
+	}
+	if namespace != "team-a" {
+		t.Errorf("X-Vault-Namespace = %q, want %q", namespace, "team-a")
+	}
+}
+
+func TestVaultProviderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		kvVersion int
+		status    int
+		body      string
+		wantErr   string
+	}{
+		{
+			name:      "missing secret",
+			token:     "test-token",
+			kvVersion: 1,
+			status:    http.StatusNotFound,
+			body:      `{"errors":[]}`,
+			wantErr:   "secret task-manager not found in Vault mount secret",
+		},
+		{
+			name:      "wrong token",
+			token:     "other-token",
+			kvVersion: 1,
+			wantErr:   "permission denied",
+		},
+		{
+			name:      "deleted kv version 2 secret",
+			token:     "test-token",
+			kvVersion: 2,
+			status:    http.StatusOK,
+			body:      `{"data":{"data":null,"metadata":{"deletion_time":"2024-01-01T00:00:00Z"}}}`,
+			wantErr:   "has no data",
+		},
+		{
+			name:      "nested value",
+			token:     "test-token",
+			kvVersion: 1,
+			status:    http.StatusOK,
+			body:      `{"data":{"DB_PASSWORD":{"value":"s3cret"}}}`,
+			wantErr:   "value of DB_PASSWORD is not a string",
+		},
+		{
+			name:      "invalid json",
+			token:     "test-token",
+			kvVersion: 1,
+			status:    http.StatusOK,
+			body:      `<html>`,
+			wantErr:   "failed to decode Vault response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/v1/secret/task-manager"
+			if tt.kvVersion == 2 {
+				path = "/v1/secret/data/task-manager"
+			}
+			srv := newTestVault(t, path, tt.status, tt.body)
+			p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: tt.token, Mount: "secret", KVVersion: tt.kvVersion})
+
+			_, err := p.Secrets(context.Background(), "task-manager")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Secrets() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
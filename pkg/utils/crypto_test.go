@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestEncryptStringRoundTrip(t *testing.T) {
+	for _, plaintext := range []string{"", "JBSWY3DPEHPK3PXP", strings.Repeat("x", 1024)} {
+		ciphertext, err := EncryptString(plaintext, "key")
+		if err != nil {
+			t.Fatalf("EncryptString: %v", err)
+		}
+		if plaintext != "" && strings.Contains(ciphertext, plaintext) {
+			t.Errorf("ciphertext contains the plaintext")
+		}
+
+		got, err := DecryptString(ciphertext, "key")
+		if err != nil || got != plaintext {
+			t.Errorf("DecryptString = %q, %v; want %q", got, err, plaintext)
+		}
+	}
+}
+
+func TestEncryptStringUsesFreshNonces(t *testing.T) {
+	a, _ := EncryptString("secret", "key")
+	b, _ := EncryptString("secret", "key")
+	if a == b {
+		t.Error("encrypting the same plaintext twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptStringRejects(t *testing.T) {
+	ciphertext, err := EncryptString("secret", "key")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(ciphertext)
+	sealed[len(sealed)-1] ^= 0x01
+	tampered := base64.StdEncoding.EncodeToString(sealed)
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		secret     string
+	}{
+		{"wrong key", ciphertext, "other key"},
+		{"tampered ciphertext", tampered, "key"},
+		{"not base64", "%%%", "key"},
+		{"too short", base64.StdEncoding.EncodeToString([]byte("short")), "key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := DecryptString(tt.ciphertext, tt.secret); err == nil {
+				t.Errorf("DecryptString = %q, want an error", got)
+			}
+		})
+	}
+}
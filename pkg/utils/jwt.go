@@ -11,16 +11,21 @@ import (
 type CustomClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// SessionVersion must match the user's current session version for the token to be accepted
+	SessionVersion int `json:"sv"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken generates a JWT token
-func GenerateToken(userID, email, secret string, expiryHours int) (string, error) {
+func GenerateToken(userID, email, role string, sessionVersion int, secret string, expiryHours int) (string, error) {
 	expirationTime := time.Now().Add(time.Hour * time.Duration(expiryHours))
 
 	claims := &CustomClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:         userID,
+		Email:          email,
+		Role:           role,
+		SessionVersion: sessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -58,3 +63,59 @@ func ValidateToken(tokenString, secret string) (*CustomClaims, error) {
 
 	return claims, nil
 }
+
+// ChallengeClaims identify a user part-way through a multi-step flow, such as a
+// login waiting for a second factor. They are not accepted as access tokens.
+type ChallengeClaims struct {
+	UserID  string `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// challengeKey derives a per-purpose signing key so challenge tokens never validate as access tokens
+func challengeKey(secret, purpose string) []byte {
+	return []byte(secret + ":challenge:" + purpose)
+}
+
+// GenerateChallengeToken generates a short-lived token for the given purpose
+func GenerateChallengeToken(userID, purpose, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ChallengeClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(challengeKey(secret, purpose))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateChallengeToken validates a challenge token issued for the given purpose
+func ValidateChallengeToken(tokenString, purpose, secret string) (*ChallengeClaims, error) {
+	claims := &ChallengeClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return challengeKey(secret, purpose), nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenge token: %w", err)
+	}
+
+	if !token.Valid || claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid challenge token")
+	}
+
+	return claims, nil
+}
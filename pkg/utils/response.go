@@ -1,23 +0,0 @@
-package utils
-
-import "github.com/gin-gonic/gin"
-
-type Response struct {
-	Status string      `json:"status"`
-	Data   interface{} `json:"data,omitempty"`
-	Error  string      `json:"error,omitempty"`
-}
-
-func SuccessResponse(c *gin.Context, statusCode int, data interface{}) {
-	c.JSON(statusCode, Response{
-		Status: "success",
-		Data:   data,
-	})
-}
-
-func ErrorResponse(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, Response{
-		Status: "error",
-		Error:  message,
-	})
-}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; the last 6 digits are the 6-digit codes
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	previous, _ := TOTPCode(rfc6238Secret, step-1)
+	tooOld, _ := TOTPCode(rfc6238Secret, step-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", "050471", true, step},
+		{"surrounding spaces", " 050471 ", true, step},
+		{"previous step within skew", previous, true, step - 1},
+		{"outside skew", tooOld, false, 0},
+		{"wrong code", "000000", false, 0},
+		{"wrong length", "50471", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfc6238Secret, tt.code, now, 1)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v; want %d, %v", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	if _, err := TOTPCode(secret, 0); err != nil {
+		t.Errorf("generated secret %q is not usable: %v", secret, err)
+	}
+	if other, _ := GenerateTOTPSecret(); other == secret {
+		t.Error("GenerateTOTPSecret returned the same secret twice")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Task Manager", "ada@example.com", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("invalid URI %q: %v", uri, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Task Manager:ada@example.com" {
+		t.Errorf("URI %q has the wrong type or label", uri)
+	}
+	query := u.Query()
+	if query.Get("secret") != rfc6238Secret || query.Get("issuer") != "Task Manager" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("URI %q has wrong parameters", uri)
+	}
+}